@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ishunyu/shithead/internal/engine"
+	"github.com/ishunyu/shithead/internal/lobby"
+	"github.com/ishunyu/shithead/internal/protocol"
+	"github.com/ishunyu/shithead/internal/session"
+)
+
+// How lobby errors are reported to the client. Anything else is a bad message.
+var errorCodes = map[error]protocol.ErrorCode{
+	lobby.ErrNoSuchRoom:     protocol.Error_NoSuchRoom,
+	lobby.ErrRoomFull:       protocol.Error_RoomFull,
+	lobby.ErrInRoom:         protocol.Error_InRoom,
+	lobby.ErrNotInRoom:      protocol.Error_NotInGame,
+	lobby.ErrNotHost:        protocol.Error_NotHost,
+	lobby.ErrGameStarted:    protocol.Error_GameStarted,
+	lobby.ErrNotStarted:     protocol.Error_NotStarted,
+	lobby.ErrInvalidRoom:    protocol.Error_InvalidRoom,
+	engine.ErrTooFewPlayers: protocol.Error_InvalidRoom,
+}
+
+const (
+	// How many messages can wait to be written before the client is dropped as too slow
+	sendQueueSize = 64
+	// How long writing one message can take
+	writeWait = 10 * time.Second
+	// How long the client has to answer a ping before the connection is treated as dead
+	pongWait = time.Minute
+	// How often the client is pinged. Shorter than pongWait, so a live client always answers in time
+	pingPeriod = pongWait * 9 / 10
+)
+
+// A client is one WebSocket connection speaking the JSON protocol on behalf of a session. It is a member of the lobby.
+type client struct {
+	conn   *websocket.Conn
+	token  session.Token
+	server *server
+
+	// Rooms send to the client while holding their locks, so messages are queued and written by the client's own goroutine
+	outbound  chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newClient(conn *websocket.Conn, token session.Token, server *server) *client {
+	return &client{
+		conn:     conn,
+		token:    token,
+		server:   server,
+		outbound: make(chan []byte, sendQueueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// Queues the message without waiting for it to be written. A client that falls too far behind is disconnected.
+func (c *client) Send(envelope protocol.Envelope) {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		log.Printf("Error %s when encoding %s message", err, envelope.Type)
+		return
+	}
+
+	select {
+	case <-c.done:
+	case c.outbound <- data:
+	default:
+		log.Printf("Dropping client that is too slow to keep up")
+		c.conn.Close()
+	}
+}
+
+// Writes queued messages and pings until the client is closed, then writes whatever is still queued and closes the connection.
+func (c *client) write() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	defer c.conn.Close()
+	for {
+		select {
+		case data := <-c.outbound:
+			if !c.writeMessage(websocket.TextMessage, data) {
+				return
+			}
+		case <-ticker.C:
+			if !c.writeMessage(websocket.PingMessage, nil) {
+				return
+			}
+		case <-c.done:
+			for {
+				select {
+				case data := <-c.outbound:
+					if !c.writeMessage(websocket.TextMessage, data) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *client) writeMessage(messageType int, data []byte) bool {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := c.conn.WriteMessage(messageType, data); err != nil {
+		log.Printf("Error %s when sending message to client", err)
+		return false
+	}
+	return true
+}
+
+// Stops the client once what has been sent to it is written. It is safe to call more than once.
+func (c *client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *client) sendError(requestId string, code protocol.ErrorCode, message string) {
+	c.Send(protocol.NewError(requestId, code, engine.Success, message))
+}
+
+// Decodes a message from the client and replies to it. Every request gets a reply, errors included.
+// Returns false once the client's session is over and the connection should be closed.
+func (c *client) handle(message []byte) bool {
+	request, err := protocol.Decode(message)
+	if !c.server.sessions.Touch(c.token) {
+		c.sendError(request.RequestId, protocol.Error_Unauthorized, "Session has ended")
+		return false
+	}
+	if err != nil {
+		c.sendError(request.RequestId, protocol.Error_BadMessage, err.Error())
+		return true
+	}
+	if request.Version != protocol.Version {
+		c.sendError(request.RequestId, protocol.Error_UnsupportedVersion, fmt.Sprintf("Unsupported protocol version %d, expected %d", request.Version, protocol.Version))
+		return true
+	}
+	if !request.Type.IsClient() {
+		c.sendError(request.RequestId, protocol.Error_UnknownType, fmt.Sprintf("Unknown message type %q", request.Type))
+		return true
+	}
+
+	payload := payloadOf(request.Type)
+	if payload != nil {
+		if err := request.DecodePayload(payload); err != nil {
+			c.sendError(request.RequestId, protocol.Error_BadMessage, err.Error())
+			return true
+		}
+	}
+
+	if err := c.dispatch(request, payload); err != nil {
+		c.replyError(request.RequestId, err)
+	}
+	return true
+}
+
+// Carries out the request. Replies are sent by the lobby and rooms, except for those that don't involve a room.
+func (c *client) dispatch(request protocol.Envelope, payload any) error {
+	lobbyRequest := lobby.Request{Member: c, Id: request.RequestId}
+
+	switch request.Type {
+	case protocol.Type_Create:
+		create := payload.(*protocol.CreatePayload)
+		_, err := c.server.lobby.Create(lobbyRequest, create.Name, create.Rules, create.Seats)
+		return err
+	case protocol.Type_List:
+		c.sendRooms(request.RequestId)
+		return nil
+	case protocol.Type_Join:
+		join := payload.(*protocol.JoinPayload)
+		_, err := c.server.lobby.Join(lobbyRequest, join.RoomId, join.Name)
+		return err
+	case protocol.Type_Leave:
+		if err := c.server.lobby.Leave(c); err != nil {
+			return err
+		}
+		c.sendRooms(request.RequestId)
+		return nil
+	}
+
+	room := c.server.lobby.RoomOf(c)
+	if room == nil {
+		return lobby.ErrNotInRoom
+	}
+	switch request.Type {
+	case protocol.Type_Start:
+		return room.Start(lobbyRequest)
+	case protocol.Type_Play:
+		return room.Play(lobbyRequest, *payload.(*protocol.PlayPayload))
+	case protocol.Type_PickUp:
+		return room.PickUp(lobbyRequest)
+	case protocol.Type_Swap:
+		return room.Swap(lobbyRequest, *payload.(*protocol.SwapPayload))
+	case protocol.Type_Ready:
+		return room.Ready(lobbyRequest)
+	default:
+		return fmt.Errorf("Unhandled message type %q", request.Type)
+	}
+}
+
+func (c *client) sendRooms(requestId string) {
+	envelope, err := protocol.NewEnvelope(protocol.Type_Rooms, requestId, protocol.RoomsPayload{Rooms: c.server.lobby.Open()})
+	if err != nil {
+		log.Printf("Error %s when building rooms message", err)
+		return
+	}
+	c.Send(envelope)
+}
+
+func (c *client) replyError(requestId string, err error) {
+	var rejected *lobby.RejectedError
+	if errors.As(err, &rejected) {
+		c.Send(protocol.NewError(requestId, protocol.Error_Rejected, rejected.Status, err.Error()))
+		return
+	}
+	for target, code := range errorCodes {
+		if errors.Is(err, target) {
+			c.sendError(requestId, code, err.Error())
+			return
+		}
+	}
+	c.sendError(requestId, protocol.Error_BadMessage, err.Error())
+}
+
+// Returns somewhere to decode the payload of the client message type into, or nil if it has no payload.
+func payloadOf(messageType protocol.MessageType) any {
+	switch messageType {
+	case protocol.Type_Create:
+		return &protocol.CreatePayload{}
+	case protocol.Type_Join:
+		return &protocol.JoinPayload{}
+	case protocol.Type_Play:
+		return &protocol.PlayPayload{}
+	case protocol.Type_Swap:
+		return &protocol.SwapPayload{}
+	default:
+		return nil
+	}
+}
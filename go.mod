@@ -2,4 +2,4 @@ module github.com/ishunyu/shithead
 
 go 1.23
 
-require github.com/gorilla/websocket v1.5.3 // indirect
+require github.com/gorilla/websocket v1.5.3
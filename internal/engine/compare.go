@@ -6,11 +6,31 @@ func (game *Game) compareCards(a Card, b Card) int {
 	return game.comparator.Compare(a, b)
 }
 
-func newGameComparator(compareFunc cardComparatorFunc) CardComparator {
-	return CardComparatorImpl{
-		compareFunc: compareFunc,
-		next:        &BasicComparator,
+func (game *Game) canPlayOn(card Card, topCard Card) bool {
+	return game.compareCards(card, topCard) >= 0
+}
+
+// Chains the compare functions in order in front of BasicComparator.
+// Cards of the same rank can always be played on each other, so that is checked first.
+func newGameComparator(compareFuncs ...cardComparatorFunc) CardComparator {
+	compareFuncs = append([]cardComparatorFunc{matchRank}, compareFuncs...)
+
+	comparator := BasicComparator
+	for i := len(compareFuncs) - 1; i >= 0; i-- {
+		next := comparator
+		comparator = CardComparatorImpl{
+			compareFunc: compareFuncs[i],
+			next:        &next,
+		}
+	}
+	return comparator
+}
+
+func matchRank(a, b Card) (int, comparatorState) {
+	if a.Rank == b.Rank {
+		return 0, _terminate
 	}
+	return 0, _continue
 }
 
 func (game *Game) leftOf(playerId int) int {
@@ -21,10 +41,22 @@ func (game *Game) rightOf(playerId int) int {
 	return (playerId + 1) % len(game.Hands)
 }
 
+// Play goes to the right while direction is positive and to the left once it has been reversed.
+// Players who have finished are skipped.
 func (game *Game) nextPlayerId() int {
-	return (game.currentPlayerId + game.direction + len(game.Hands)) % len(game.Hands)
+	playerId := game.currentPlayerId
+	for {
+		if game.direction < 0 {
+			playerId = game.leftOf(playerId)
+		} else {
+			playerId = game.rightOf(playerId)
+		}
+		if !game.isFinished(playerId) || playerId == game.currentPlayerId {
+			return playerId
+		}
+	}
 }
 
 func (game *Game) isNextTo(playerAId int, playerBId int) bool {
 	return game.leftOf(playerAId) == playerBId || game.rightOf(playerAId) == playerBId
-}
\ No newline at end of file
+}
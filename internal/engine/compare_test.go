@@ -124,3 +124,115 @@ func TestJoker(t *testing.T) {
 		testCompareWithJoker(spades, joker)
 	}
 }
+
+func TestStandardRules(t *testing.T) {
+	comparator := newRuleComparator(StandardRules.rules())
+
+	testCases := []struct {
+		card    Card
+		topCard Card
+		canPlay bool
+	}{
+		{clubs[4], spades[4], true},   // Same rank
+		{clubs[4], spades[5], false},  // Lower
+		{clubs[1], spades[12], true},  // Two on anything
+		{clubs[4], spades[1], true},   // Anything on two
+		{clubs[2], spades[12], true},  // Three on anything
+		{clubs[9], spades[12], true},  // Ten on anything
+		{clubs[4], spades[6], true},   // Lower on seven
+		{clubs[6], spades[6], true},   // Seven on seven
+		{clubs[7], spades[6], false},  // Higher on seven
+		{clubs[1], spades[6], true},   // Two on seven
+		{jokers[0], spades[6], false}, // Joker on seven
+		{jokers[0], spades[12], true}, // Joker on king
+		{clubs[12], jokers[1], false}, // King on joker
+	}
+
+	for _, testCase := range testCases {
+		canPlay := comparator.Compare(testCase.card, testCase.topCard) >= 0
+		if canPlay != testCase.canPlay {
+			t.Errorf("Expected playing %v on %v to be %t", testCase.card, testCase.topCard, testCase.canPlay)
+		}
+	}
+}
+
+func TestRuleOrder(t *testing.T) {
+	// The lower card listed first must not stop cards that go on anything
+	ruleSet := StandardRules.clone()
+	ruleSet.SpecialCards = []SpecialCard{
+		{Seven, Power_Lower},
+		{Ten, Power_Burn},
+		{Two, Power_Reset},
+		{Three, Power_Invisible},
+	}
+	comparator := newRuleComparator(ruleSet.rules())
+
+	testCases := []struct {
+		card    Card
+		topCard Card
+		canPlay bool
+	}{
+		{clubs[9], spades[6], true},  // Ten on seven
+		{clubs[1], spades[6], true},  // Two on seven
+		{clubs[2], spades[6], true},  // Three on seven
+		{clubs[4], spades[6], true},  // Lower on seven
+		{clubs[7], spades[6], false}, // Higher on seven
+		{clubs[0], spades[6], false}, // Ace on seven, with Aces high
+	}
+
+	for _, testCase := range testCases {
+		canPlay := comparator.Compare(testCase.card, testCase.topCard) >= 0
+		if canPlay != testCase.canPlay {
+			t.Errorf("Expected playing %v on %v to be %t", testCase.card, testCase.topCard, testCase.canPlay)
+		}
+	}
+
+	// With Aces low, an Ace is lower than a seven
+	ruleSet.AceHigh = false
+	if newRuleComparator(ruleSet.rules()).Compare(clubs[0], spades[6]) < 0 {
+		t.Errorf("Expected %v to be playable on %v with Aces low", clubs[0], spades[6])
+	}
+}
+
+func TestNoRules(t *testing.T) {
+	comparator := newRuleComparator(nil)
+
+	if comparator.Compare(clubs[1], spades[12]) >= 0 {
+		t.Errorf("Expected %v to be too low for %v without rules", clubs[1], spades[12])
+	}
+	if comparator.Compare(clubs[9], spades[12]) >= 0 {
+		t.Errorf("Expected %v to be too low for %v without rules", clubs[9], spades[12])
+	}
+	if comparator.Compare(clubs[12], spades[12]) < 0 {
+		t.Errorf("Expected %v to be playable on %v", clubs[12], spades[12])
+	}
+}
+
+func TestAceHigh(t *testing.T) {
+	comparator := newRuleComparator(StandardRules.rules())
+
+	testCases := []struct {
+		card    Card
+		topCard Card
+		canPlay bool
+	}{
+		{clubs[0], spades[12], true},  // Ace on king
+		{clubs[12], spades[0], false}, // King on ace
+		{clubs[0], spades[6], false},  // Ace on seven
+		{clubs[1], spades[0], true},   // Two on ace
+		{jokers[0], spades[0], true},  // Joker on ace
+	}
+
+	for _, testCase := range testCases {
+		canPlay := comparator.Compare(testCase.card, testCase.topCard) >= 0
+		if canPlay != testCase.canPlay {
+			t.Errorf("Expected playing %v on %v to be %t", testCase.card, testCase.topCard, testCase.canPlay)
+		}
+	}
+
+	for i := 1; i < len(clubs); i++ {
+		if AceHighComparator.Compare(clubs[i], clubs[0]) >= 0 {
+			t.Errorf("Expected %v to be less than %v", clubs[i], clubs[0])
+		}
+	}
+}
@@ -16,6 +16,27 @@ func NumericCompare(a, b Card) int {
 	return int(a.Suit) - int(b.Suit)
 }
 
+// Compares cards like NumericCompare, except that the Ace ranks above the King.
+func AceHighCompare(a, b Card) int {
+	if a == b {
+		return 0
+	}
+
+	rankDiff := aceHighRank(a.Rank) - aceHighRank(b.Rank)
+	if rankDiff != 0 {
+		return rankDiff
+	}
+
+	return int(a.Suit) - int(b.Suit)
+}
+
+func aceHighRank(rank Rank) int {
+	if rank == Ace {
+		return int(King) + 1
+	}
+	return int(rank)
+}
+
 type cardComparatorFunc func(a, b Card) (int, comparatorState)
 
 type CardComparator interface {
@@ -40,10 +61,12 @@ type CardComparatorImpl struct {
 // If it returns _continue, it passes the comparison to the next comparator in the chain.
 func (comparator CardComparatorImpl) Compare(a, b Card) int {
 	t, c := comparator.compareFunc(a, b)
-	if c == _terminate {
+	switch c {
+	case _terminate:
 		return t
+	default:
+		return (*comparator.next).Compare(a, b)
 	}
-	return (*comparator.next).Compare(a, b)
 }
 
 var BasicComparator CardComparator = CardComparatorImpl{
@@ -53,6 +76,13 @@ var BasicComparator CardComparator = CardComparatorImpl{
 	next: nil,
 }
 
+var AceHighComparator CardComparator = CardComparatorImpl{
+	compareFunc: func(a, b Card) (int, comparatorState) {
+		return AceHighCompare(a, b), _terminate
+	},
+	next: nil,
+}
+
 func min(a, b Card, comparatorFunc CardCompare) Card {
 	if comparatorFunc(a, b) <= 0 {
 		return a
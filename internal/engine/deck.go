@@ -97,14 +97,19 @@ func (rank Rank) String() string {
 	}
 }
 
+// Deck tells apart otherwise identical copies of a card when a game is played with several decks.
 type Card struct {
-	Suit Suit
-	Rank Rank
+	Suit Suit  `json:"suit"`
+	Rank Rank  `json:"rank"`
+	Deck uint8 `json:"deck"`
 }
 
 var ErrorCard Card = Card{Suit: ErrorSuit, Rank: ErrorRank}
 
 func (card Card) String() string {
+	if card.Deck != 0 {
+		return fmt.Sprintf("(%s, %d, %d)", card.Suit, card.Rank, card.Deck)
+	}
 	return fmt.Sprintf("(%s, %d)", card.Suit, card.Rank)
 }
 
@@ -117,17 +122,18 @@ func newStandardDeck() []Card {
 	cards := make([]Card, 0, 54)
 	for _, suit := range suits {
 		for _, rank := range ranks {
-			cards = append(cards, Card{suit, rank})
+			cards = append(cards, Card{Suit: suit, Rank: rank})
 		}
 	}
 
-	cards = append(cards, Card{JokerSmall, Joker})
-	cards = append(cards, Card{JokerLarge, Joker})
+	cards = append(cards, Card{Suit: JokerSmall, Rank: Joker})
+	cards = append(cards, Card{Suit: JokerLarge, Rank: Joker})
 
 	return cards
 }
 
 func validate(card Card) bool {
+	card.Deck = 0
 	return slices.Contains[[]Card](StandardDeck, card)
 }
 
@@ -136,30 +142,64 @@ type Deck struct {
 }
 
 func NewDeck() *Deck {
-	numOfCards := 54
-	suits := []Suit{Club, Diamond, Heart, Spade}
-	ranks := []Rank{Ace, Two, Three, Four, Five, Six, Seven, Eight, Nine, Ten, Jack, Queen, King}
-	standardDeck := make([]Card, 0, numOfCards)
+	return NewDeckFromSource(randomSource())
+}
 
-	for _, suit := range suits {
-		for _, rank := range ranks {
-			standardDeck = append(standardDeck, Card{suit, rank})
-		}
+// Creates a deck shuffled by the source, so the same source state always gives the same order.
+func NewDeckFromSource(src rand.Source) *Deck {
+	return newDeck(rand.New(src), 1, true)
+}
+
+// Creates numOfDecks standard decks shuffled together. Each copy of a card is marked with the deck it came from.
+func NewDecks(numOfDecks int) *Deck {
+	return newDeck(rand.New(randomSource()), numOfDecks, true)
+}
+
+// Creates shuffled decks, leaving out the jokers if they aren't wanted.
+func newDeck(rng *rand.Rand, numOfDecks int, jokers bool) *Deck {
+	standardDeck := newStandardDeck()
+	if !jokers {
+		standardDeck = slices.DeleteFunc(standardDeck, func(c Card) bool {
+			return c.Rank == Joker
+		})
 	}
 
-	standardDeck = append(standardDeck, Card{JokerSmall, Joker})
-	standardDeck = append(standardDeck, Card{JokerLarge, Joker})
+	cards := make([]Card, 0, numOfDecks*len(standardDeck))
+	for d := 0; d < numOfDecks; d++ {
+		for _, card := range standardDeck {
+			card.Deck = uint8(d)
+			cards = append(cards, card)
+		}
+	}
 
+	numOfCards := len(cards)
 	deck := make([]Card, 0, numOfCards)
-	shuffle := rand.Perm(numOfCards)
+	shuffle := rng.Perm(numOfCards)
 
 	for _, i := range shuffle {
-		deck = append(deck, standardDeck[i])
+		deck = append(deck, cards[i])
 	}
 
 	return &Deck{deck}
 }
 
+func randomSource() rand.Source {
+	return rand.NewPCG(rand.Uint64(), rand.Uint64())
+}
+
+// Returns a source that always produces the same numbers for the seed. Different streams of the same seed are independent.
+func seededSource(seed uint64, stream uint64) rand.Source {
+	return rand.NewPCG(seed, stream)
+}
+
+// Returns the number of cards in a single deck.
+func deckSize(jokers bool) int {
+	if jokers {
+		return len(StandardDeck)
+	}
+	return len(StandardDeck) - 2
+}
+
 func (deck *Deck) DrawCard() (Card, error) {
 	if len(deck.Cards) == 0 {
 		return ErrorCard, fmt.Errorf("Deck is empty")
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"math/rand/v2"
 	"slices"
 	"testing"
 )
@@ -37,3 +38,37 @@ func testAgainstStandardDeck(t *testing.T, deck *Deck) {
 		t.Fatalf("Deck does not conform to a standard deck.")
 	}
 }
+
+func TestMultipleDecks(t *testing.T) {
+	numOfDecks := 3
+	deck := NewDecks(numOfDecks)
+
+	decks := make([][]Card, numOfDecks)
+	for _, card := range deck.Cards {
+		if int(card.Deck) >= numOfDecks {
+			t.Fatalf("Card is from an unknown deck: %s", card)
+		}
+		original := card
+		original.Deck = 0
+		decks[card.Deck] = append(decks[card.Deck], original)
+	}
+
+	for _, cards := range decks {
+		testAgainstStandardDeck(t, &Deck{cards})
+	}
+}
+
+func TestDeckFromSource(t *testing.T) {
+	deck := NewDeckFromSource(rand.NewPCG(42, 0))
+	testAgainstStandardDeck(t, deck)
+
+	same := NewDeckFromSource(rand.NewPCG(42, 0))
+	if !slices.Equal(deck.Cards, same.Cards) {
+		t.Fatalf("Decks from the same seed should match.\n%s\n%s", deck, same)
+	}
+
+	different := NewDeckFromSource(rand.NewPCG(43, 0))
+	if slices.Equal(deck.Cards, different.Cards) {
+		t.Fatalf("Decks from different seeds should not match.\n%s\n%s", deck, different)
+	}
+}
@@ -1,49 +1,90 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"slices"
 )
 
 type Game struct {
+	Seed            uint64
 	DrawPile        *Deck
 	InPlayPile      *Deck
 	DiscardPile     *Deck
 	Hands           []Hand
+	ruleSet         RuleSet
+	rules           []Rule
 	comparator      CardComparator
 	round           int
 	currentPlayerId int
 	direction       int
+	finished        []int
+	log             []Event
+	undoHistory     []Snapshot
+	redoHistory     []Snapshot
 }
 
 const NotStartedPlayerId int = -1
 const EndedPlayerId int = -2
 const ErrorPlayerId int = -3
 
-func (game *Game) CurrentHand() Hand {
-	if game.currentPlayerId == NotStartedPlayerId {
-		game.Init()
+const burnRunLength int = 4
+
+// Random streams drawn from a game's seed
+const (
+	deckStream           uint64 = 0
+	startingPlayerStream uint64 = 1
+)
+
+// Returns the hand of the player whose turn it is. There is none before the game starts or once it is over.
+func (game *Game) CurrentHand() (Hand, bool) {
+	if game.currentPlayerId < 0 {
+		return Hand{}, false
 	}
-	return game.Hands[game.currentPlayerId]
+	return game.Hands[game.currentPlayerId].clone(), true
+}
+
+// Creates a game and deals every hand. It fails if the rule set is invalid
+// or the number of players can't be dealt from the rule set's decks.
+func NewGame(numOfPlayers int, ruleSet RuleSet) (*Game, error) {
+	return NewSeededGame(numOfPlayers, ruleSet, rand.Uint64())
 }
 
-func NewGame(numOfPlayers int) *Game {
-	deck := NewDeck()
+// Creates a game like NewGame, but every random choice is made from the seed.
+// Two games with the same seed, rule set and plays end up in the same state.
+func NewSeededGame(numOfPlayers int, ruleSet RuleSet, seed uint64) (*Game, error) {
+	if err := ruleSet.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ruleSet.ValidatePlayers(numOfPlayers); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(seededSource(seed, deckStream))
+	deck := newDeck(rng, ruleSet.numOfDecks(numOfPlayers), ruleSet.Jokers != JokerMode_None)
 	hands := make([]Hand, 0, numOfPlayers)
 	for i := 0; i < numOfPlayers; i++ {
 		hands = append(hands, Hand{
 			Id:       i,
-			InHand:   make([]Card, 0, 3),
-			FaceUp:   make([]Card, 0, 3),
-			FaceDown: make([]Card, 0, 3),
+			InHand:   make([]Card, 0, ruleSet.InHandCards),
+			FaceUp:   make([]Card, 0, ruleSet.FaceUpCards),
+			FaceDown: make([]Card, 0, ruleSet.FaceDownCards),
 		})
 	}
 
 	// Deal hands
-	dealCard(deck, hands, 3, (*Hand).dealFaceDown)
-	dealCard(deck, hands, 3, (*Hand).dealFaceUp)
-	dealCard(deck, hands, 3, (*Hand).dealInHand)
+	err := errors.Join(
+		dealCard(deck, hands, ruleSet.FaceDownCards, (*Hand).dealFaceDown),
+		dealCard(deck, hands, ruleSet.FaceUpCards, (*Hand).dealFaceUp),
+		dealCard(deck, hands, ruleSet.InHandCards, (*Hand).dealInHand),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Not enough cards to deal %d players: %w", numOfPlayers, err)
+	}
 
-	return &Game{
+	game := &Game{
+		Seed:            seed,
 		DrawPile:        deck,
 		InPlayPile:      &Deck{Cards: make([]Card, 0)},
 		DiscardPile:     &Deck{Cards: make([]Card, 0)},
@@ -51,103 +92,507 @@ func NewGame(numOfPlayers int) *Game {
 		round:           0,
 		currentPlayerId: NotStartedPlayerId,
 		direction:       1,
-		comparator:      BasicComparator,
+		finished:        make([]int, 0, numOfPlayers),
+		log:             make([]Event, 0),
 	}
+	game.setRuleSet(ruleSet)
+
+	rules := ruleSet.clone()
+	game.emit(Event{Type: Event_Create, PlayerId: NotStartedPlayerId, Seed: seed, Rules: &rules, NumOfPlayers: numOfPlayers})
+	for _, hand := range game.Hands {
+		game.emit(Event{Type: Event_Deal, PlayerId: hand.Id, Zone: Zone_FaceDown, Cards: slices.Clone(hand.FaceDown)})
+		game.emit(Event{Type: Event_Deal, PlayerId: hand.Id, Zone: Zone_FaceUp, Cards: slices.Clone(hand.FaceUp)})
+		game.emit(Event{Type: Event_Deal, PlayerId: hand.Id, Zone: Zone_InHand, Cards: slices.Clone(hand.InHand)})
+	}
+	return game, nil
 }
 
+// Applies the play to the game. The whole play is validated first,
+// so a rejected play leaves the game exactly as it was.
 func (game *Game) PlayHand(play Play) PlayResult {
+	status := game.ValidatePlay(play)
+	if status != Success {
+		return game.rejectPlay(status)
+	}
+
+	game.saveUndo()
+	game.emit(Event{Type: Event_Play, PlayerId: play.PlayerId, Kind: play.Kind, Cards: slices.Clone(play.Cards), Slot: play.Slot})
+
+	switch play.Kind {
+	case PlayCards:
+		return game.playCards(play)
+	case PickUpPile:
+		return game.pickUpPile(play)
+	default:
+		return game.playFaceDown(play)
+	}
+}
+
+// Returns the Status PlayHand would fail with, or Success, without changing the game.
+func (game *Game) ValidatePlay(play Play) Status {
+	if game.IsOver() {
+		return Play_GameOver
+	}
+
+	if !game.isPlayer(play.PlayerId) {
+		return Play_UnknownPlayer
+	}
+
 	// Check the correct player played the turn
-	if play.Hand.Id != game.currentPlayerId {
-		return PlayResult{
-			Round:        game.round,
-			Success:      false,
-			Status:       Play_WrongPlayer,
-			NextPlayerId: game.currentPlayerId,
+	if play.PlayerId != game.currentPlayerId {
+		return Play_WrongPlayer
+	}
+
+	switch play.Kind {
+	case PlayCards:
+		return game.validateCards(play)
+	case PickUpPile:
+		if len(game.InPlayPile.Cards) == 0 {
+			return Play_PileEmpty
 		}
+		return Success
+	case PlayFaceDown:
+		// Whether the card beats the pile is only found out once it has been flipped
+		return game.handOf(play).checkFaceDown(play.Slot)
+	default:
+		return Error
 	}
+}
 
-	// Check if the card is in the player's hand
-	status := play.Hand.removeCard(play.Card)
+func (game *Game) validateCards(play Play) Status {
+	// A player who can't beat the pile has to pick it up
+	if !game.CanPlay() {
+		return Play_MustPickUp
+	}
+
+	// Check the cards can be played together
+	status := play.validateCards()
 	if status != Success {
-		return PlayResult{
-			Round:        game.round,
-			Success:      false,
-			Status:       status,
-			NextPlayerId: game.currentPlayerId,
-		}
-	}
-
-	// Check if the card is higher than the top of the in play pile
-	if len(game.InPlayPile.Cards) > 0 {
-		topCard := game.InPlayPile.Cards[len(game.InPlayPile.Cards)-1]
-		if game.compareCards(play.Card, topCard) < 0 {
-			return PlayResult{
-				Round:        game.round,
-				Success:      false,
-				Status:       Play_CardTooLow,
-				NextPlayerId: game.currentPlayerId,
+		return status
+	}
+
+	// Check if the cards are in the player's hand
+	status = game.handOf(play).hasCards(play.Cards...)
+	if status != Success {
+		return status
+	}
+
+	// Check if the cards are higher than the top of the in play pile
+	if topCard, ok := game.topCard(); ok {
+		for _, card := range play.Cards {
+			if !game.canPlayOn(card, topCard) {
+				return Play_CardTooLow
 			}
 		}
 	}
 
-	game.InPlayPile.AddCard(play.Card)
-	drawCard, error := game.DrawPile.DrawCard()
-	if error == nil {
-		play.Hand.InHand = append(play.Hand.InHand, drawCard)
+	return Success
+}
+
+func (game *Game) playCards(play Play) PlayResult {
+	game.handOf(play).removeCard(play.Cards...)
+	return game.placeCards(play)
+}
+
+// Flips the face down card in the play's slot. If it can't beat the pile,
+// the player picks up the pile along with the flipped card.
+func (game *Game) playFaceDown(play Play) PlayResult {
+	card := game.handOf(play).takeFaceDown(play.Slot)
+	game.emit(Event{Type: Event_Reveal, PlayerId: play.PlayerId, Cards: []Card{card}})
+
+	if topCard, ok := game.topCard(); ok && !game.canPlayOn(card, topCard) {
+		game.InPlayPile.AddCard(card)
+		result := game.pickUpPile(play)
+		result.Revealed = card
+		return result
+	}
+
+	play.Cards = []Card{card}
+	result := game.placeCards(play)
+	result.Revealed = card
+	return result
+}
+
+// Puts the play's cards on the in play pile, which have already been checked and taken from the hand.
+func (game *Game) placeCards(play Play) PlayResult {
+	for _, card := range play.Cards {
+		game.InPlayPile.AddCard(card)
+	}
+	game.refillHand(game.handOf(play))
+
+	// Several reverse cards played together only reverse once
+	reversed := game.effectOf(play.Cards[0])&Effect_Reverse != 0
+	if reversed {
+		game.direction = -game.direction
+		game.emit(Event{Type: Event_Reverse, PlayerId: play.PlayerId, Direction: game.direction})
+	}
+
+	burned := game.shouldBurn(play)
+	if burned {
+		game.emit(Event{Type: Event_Burn, PlayerId: play.PlayerId, Cards: slices.Clone(game.InPlayPile.Cards)})
+		game.burnPile()
+	}
+
+	result := game.concludePlay(play, burned)
+	result.Reversed = reversed
+	return result
+}
+
+// Moves the whole in play pile into the player's hand and passes the turn on.
+func (game *Game) pickUpPile(play Play) PlayResult {
+	game.emit(Event{Type: Event_PickUp, PlayerId: play.PlayerId, Cards: slices.Clone(game.InPlayPile.Cards)})
+	hand := game.handOf(play)
+	hand.InHand = append(hand.InHand, game.InPlayPile.Cards...)
+	game.InPlayPile.Cards = make([]Card, 0)
+
+	result := game.concludePlay(play, false)
+	result.PickedUp = true
+	return result
+}
+
+// Reports whether the current player has at least one card they are allowed to play.
+// Face down cards are played blind, so they can always be attempted.
+func (game *Game) CanPlay() bool {
+	if game.currentPlayerId < 0 {
+		return false
+	}
+
+	hand := &game.Hands[game.currentPlayerId]
+	if len(hand.InHand) == 0 && len(hand.FaceUp) == 0 {
+		return len(hand.FaceDown) != 0
+	}
+
+	topCard, ok := game.topCard()
+	if !ok {
+		return true
+	}
+	for _, card := range hand.activeZone() {
+		if game.canPlayOn(card, topCard) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lists every play the player is allowed to make: each number of same rank cards from the zone in play
+// that beats the pile, each face down slot, and picking up the pile.
+// Cards of the same rank play alike, so for each number only the first cards of that rank in the zone are listed.
+// It is empty when it isn't the player's turn, including before the game starts.
+func (game *Game) LegalPlays(playerId int) []Play {
+	plays := make([]Play, 0)
+	if game.IsOver() || !game.isPlayer(playerId) || playerId != game.currentPlayerId {
+		return plays
+	}
+
+	hand := &game.Hands[playerId]
+	candidates := make([]Play, 0)
+	if len(hand.InHand) == 0 && len(hand.FaceUp) == 0 {
+		for slot := range hand.FaceDown {
+			candidates = append(candidates, Play{Kind: PlayFaceDown, PlayerId: playerId, Slot: slot})
+		}
+	} else {
+		for _, cards := range sameRankSets(hand.activeZone()) {
+			candidates = append(candidates, Play{Kind: PlayCards, PlayerId: playerId, Cards: cards})
+		}
+	}
+	candidates = append(candidates, Play{Kind: PickUpPile, PlayerId: playerId})
+
+	for _, play := range candidates {
+		if game.ValidatePlay(play) == Success {
+			plays = append(plays, play)
+		}
+	}
+	return plays
+}
+
+// Returns the first one, two, and so on cards of each rank, grouped by rank in the order the ranks first appear.
+func sameRankSets(zone []Card) [][]Card {
+	ranks := make([]Rank, 0, len(zone))
+	for _, card := range zone {
+		if !slices.Contains(ranks, card.Rank) {
+			ranks = append(ranks, card.Rank)
+		}
+	}
+
+	sets := make([][]Card, 0)
+	for _, rank := range ranks {
+		cards := slices.DeleteFunc(slices.Clone(zone), func(c Card) bool {
+			return c.Rank != rank
+		})
+		for count := 1; count <= len(cards); count++ {
+			sets = append(sets, slices.Clone(cards[:count]))
+		}
+	}
+	return sets
+}
+
+// Returns the card the next play has to beat, if there is one.
+// Invisible cards are skipped and mirror cards take on the card below them.
+func (game *Game) topCard() (Card, bool) {
+	for i := len(game.InPlayPile.Cards) - 1; i >= 0; i-- {
+		card := game.InPlayPile.Cards[i]
+		if game.effectOf(card)&(Effect_Invisible|Effect_Mirror) == 0 {
+			return card, true
+		}
+	}
+	return ErrorCard, false
+}
+
+func (game *Game) isPlayer(playerId int) bool {
+	return playerId >= 0 && playerId < len(game.Hands)
+}
+
+// Returns the game's own hand for the player of a play that has already been validated.
+func (game *Game) handOf(play Play) *Hand {
+	return &game.Hands[play.PlayerId]
+}
+
+func (game *Game) rejectPlay(status Status) PlayResult {
+	return PlayResult{
+		Round:        game.round,
+		Success:      false,
+		Status:       status,
+		NextPlayerId: game.currentPlayerId,
+	}
+}
+
+// The pile burns when a burn card is played or the top burnRunLength cards share the same rank.
+// Mirror cards count as the rank of the card below them.
+func (game *Game) shouldBurn(play Play) bool {
+	if game.effectOf(play.Cards[0])&Effect_Burn != 0 {
+		return true
+	}
+
+	ranks := game.pileRanks()
+	if len(ranks) < burnRunLength {
+		return false
+	}
+	topRank := ranks[len(ranks)-1]
+	for _, rank := range ranks[len(ranks)-burnRunLength:] {
+		if rank != topRank {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the rank each card in the in play pile counts as, from the bottom up.
+func (game *Game) pileRanks() []Rank {
+	ranks := make([]Rank, 0, len(game.InPlayPile.Cards))
+	for i, card := range game.InPlayPile.Cards {
+		if i > 0 && game.effectOf(card)&Effect_Mirror != 0 {
+			ranks = append(ranks, ranks[i-1])
+		} else {
+			ranks = append(ranks, card.Rank)
+		}
 	}
+	return ranks
+}
 
-	return game.concludePlay(play)
+// Moves every card in the in play pile to the discard pile.
+func (game *Game) burnPile() {
+	game.DiscardPile.Cards = append(game.DiscardPile.Cards, game.InPlayPile.Cards...)
+	game.InPlayPile.Cards = make([]Card, 0)
 }
 
-func (game *Game) concludePlay(play Play) PlayResult {
+// Draws cards until the hand holds at least the rule set's minimum or the draw pile runs out.
+func (game *Game) refillHand(hand *Hand) {
+	drawn := make([]Card, 0)
+	for len(hand.InHand) < game.ruleSet.MinHandSize {
+		card, err := game.DrawPile.DrawCard()
+		if err != nil {
+			break
+		}
+		hand.dealInHand(card)
+		drawn = append(drawn, card)
+	}
+
+	if len(drawn) != 0 {
+		game.emit(Event{Type: Event_Draw, PlayerId: hand.Id, Cards: drawn})
+	}
+}
+
+// Ends the turn. The same player goes again after burning the pile, unless they have just finished.
+// Once only one player is left, they are the shithead and the game is over.
+func (game *Game) concludePlay(play Play, burned bool) PlayResult {
 	game.round++
-	game.currentPlayerId = game.nextPlayerId()
+
+	finished := game.handOf(play).isEmpty() && !game.isFinished(play.PlayerId)
+	if finished {
+		game.finished = append(game.finished, play.PlayerId)
+		game.emit(Event{Type: Event_Finish, PlayerId: play.PlayerId})
+	}
+
+	if len(game.finished) == len(game.Hands)-1 {
+		for _, hand := range game.Hands {
+			if !game.isFinished(hand.Id) {
+				game.finished = append(game.finished, hand.Id)
+			}
+		}
+		game.currentPlayerId = EndedPlayerId
+		game.emit(Event{Type: Event_GameOver, PlayerId: game.Shithead(), Standings: game.Standings()})
+	} else if !burned || finished {
+		game.currentPlayerId = game.nextPlayerId()
+	}
 
 	return PlayResult{
 		Round:        game.round,
 		Success:      true,
 		Status:       Success,
 		NextPlayerId: game.currentPlayerId,
+		Burned:       burned,
+		GameOver:     game.IsOver(),
+		Standings:    game.Standings(),
+	}
+}
+
+func (game *Game) Rules() RuleSet {
+	return game.ruleSet.clone()
+}
+
+func (game *Game) IsOver() bool {
+	return game.currentPlayerId == EndedPlayerId
+}
+
+// Returns the ids of the players who have finished, in the order they finished.
+// Once the game is over, the last one is the shithead.
+func (game *Game) Standings() []int {
+	return slices.Clone(game.finished)
+}
+
+// Returns the id of the shithead, or ErrorPlayerId while the game is still going.
+func (game *Game) Shithead() int {
+	if !game.IsOver() {
+		return ErrorPlayerId
 	}
+	return game.finished[len(game.finished)-1]
+}
+
+func (game *Game) isFinished(playerId int) bool {
+	return slices.Contains(game.finished, playerId)
 }
 
 func (game *Game) String() string {
 	s := ""
 	s += "Game:\n"
+	s += fmt.Sprintf("Seed: %d\n", game.Seed)
 	s += "Deck: " + game.DrawPile.String() + "\n"
+	s += "InPlayPile: " + game.InPlayPile.String() + "\n"
+	s += "DiscardPile: " + game.DiscardPile.String() + "\n"
 	s += "Hands: [\n"
 	for _, hand := range game.Hands {
 		s += fmt.Sprintf("  %+v\n", hand)
 	}
 	s += "]\n"
+	s += fmt.Sprintf("round: %v\n", game.round)
 	s += fmt.Sprintf("currentPlayerId: %v\n", game.currentPlayerId)
-	s += fmt.Sprintf("comparator: %v", game.comparator)
+	s += fmt.Sprintf("direction: %v\n", game.direction)
+	s += fmt.Sprintf("finished: %v\n", game.finished)
+	s += fmt.Sprintf("rules: %s", game.ruleSet.Name)
 	return s
 }
 
-func dealCard(deck *Deck, hands []Hand, numOfRounds int, acceptCard func(hand *Hand, card Card)) {
+func dealCard(deck *Deck, hands []Hand, numOfRounds int, acceptCard func(hand *Hand, card Card)) error {
 	for r := 0; r < numOfRounds; r++ {
 		for i := range hands {
 			hand := &hands[i]
 			card, err := deck.DrawCard()
-			if err == nil {
-				acceptCard(hand, card)
+			if err != nil {
+				return err
 			}
+			acceptCard(hand, card)
+		}
+	}
+	return nil
+}
+
+// Swaps a card in hand with a face up card during the setup phase, before the hand is ready.
+func (game *Game) SwapCards(playerId int, inHand Card, faceUp Card) Status {
+	if !game.isPlayer(playerId) {
+		return Play_UnknownPlayer
+	}
+	if game.currentPlayerId != NotStartedPlayerId {
+		return Setup_GameStarted
+	}
+
+	hand := &game.Hands[playerId]
+	if hand.Ready {
+		return Setup_AlreadyReady
+	}
+
+	status := hand.swap(inHand, faceUp)
+	if status == Success {
+		game.emit(Event{Type: Event_Swap, PlayerId: hand.Id, Cards: []Card{inHand, faceUp}})
+	}
+	return status
+}
+
+// Ends the setup phase for the player's hand. The game starts once every hand is ready.
+func (game *Game) MarkReady(playerId int) Status {
+	if !game.isPlayer(playerId) {
+		return Play_UnknownPlayer
+	}
+	if game.currentPlayerId != NotStartedPlayerId {
+		return Setup_GameStarted
+	}
+
+	hand := &game.Hands[playerId]
+	if hand.Ready {
+		return Setup_AlreadyReady
+	}
+
+	hand.Ready = true
+	game.emit(Event{Type: Event_Ready, PlayerId: hand.Id})
+	for _, h := range game.Hands {
+		if !h.Ready {
+			return Success
 		}
 	}
+	game.Init()
+	return Success
 }
 
+// Picks the starting player. Calling it directly skips whatever is left of the setup phase.
+// It does nothing once the game has started.
 func (game *Game) Init() {
+	if game.currentPlayerId != NotStartedPlayerId {
+		return
+	}
+
+	for i := range game.Hands {
+		game.Hands[i].Ready = true
+	}
+
+	switch game.ruleSet.StartingPlayer {
+	case StartingPlayer_First:
+		game.currentPlayerId = 0
+	case StartingPlayer_Random:
+		game.currentPlayerId = rand.New(seededSource(game.Seed, startingPlayerStream)).IntN(len(game.Hands))
+	default:
+		game.currentPlayerId = game.lowestCardPlayerId()
+	}
+	game.emit(Event{Type: Event_Start, PlayerId: game.currentPlayerId})
+}
+
+// Cards that can be played on anything, wild cards included, never count as the lowest card.
+// If nobody holds anything else, the first player starts.
+func (game *Game) lowestCardPlayerId() int {
 	// Find player with lowest card
-	minCard := minSlice(game.Hands[0].InHand, NumericCompare)
+	ranking := game.ruleSet.ranking()
+	minCard := ErrorCard
 	startingPlayerId := 0
-	for i := 1; i < len(game.Hands); i++ {
-		hand := game.Hands[i]
-		minCardInHand := minSlice(hand.InHand, NumericCompare)
-		if game.compareCards(minCardInHand, minCard) < 0 {
+	for i, hand := range game.Hands {
+		cards := slices.DeleteFunc(slices.Clone(hand.InHand), func(c Card) bool {
+			return game.playsAnywhere(c)
+		})
+		if len(cards) == 0 {
+			continue
+		}
+		minCardInHand := minSlice(cards, ranking)
+		if minCard == ErrorCard || ranking(minCardInHand, minCard) < 0 {
 			startingPlayerId = i
 			minCard = minCardInHand
 		}
 	}
-	game.currentPlayerId = startingPlayerId
+	return startingPlayerId
 }
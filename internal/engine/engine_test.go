@@ -1,15 +1,16 @@
 package engine
 
 import (
+	"errors"
 	"slices"
 	"testing"
 )
 
 func TestGame(t *testing.T) {
 	numOfPlayers := 4
-	game := NewGame(numOfPlayers)
-	if game == nil {
-		t.Fatal("game is nil")
+	game, err := NewGame(numOfPlayers, StandardRules)
+	if err != nil {
+		t.Fatalf("Expected game to be created. Error: %s", err)
 	}
 
 	numOfPlayersActual := len(game.Hands)
@@ -39,7 +40,7 @@ func TestGame(t *testing.T) {
 
 func TestInitGame(t *testing.T) {
 	numOfPlayers := 4
-	game := NewGame(numOfPlayers)
+	game := newGame(t, numOfPlayers, StandardRules)
 
 	game.Init()
 	t.Log(game)
@@ -48,7 +49,9 @@ func TestInitGame(t *testing.T) {
 	for _, hand := range game.Hands {
 		collectedInHand = append(collectedInHand, hand.InHand...)
 	}
-	slices.SortFunc(collectedInHand, NumericCompare)
+	// Cards that go on anything don't count
+	collectedInHand = slices.DeleteFunc(collectedInHand, game.playsAnywhere)
+	slices.SortFunc(collectedInHand, AceHighCompare)
 	t.Logf("All in hand cards: %s", collectedInHand)
 
 	lowestCard := collectedInHand[0]
@@ -62,13 +65,13 @@ func TestInitGame(t *testing.T) {
 
 func TestPlayHandSuccess(t *testing.T) {
 	numOfPlayers := 4
-	game := NewGame(numOfPlayers)
+	game := newGame(t, numOfPlayers, StandardRules)
 	game.Init()
 	startingHand := &game.Hands[game.currentPlayerId]
 
 	play := Play{
-		Hand: startingHand,
-		Card: minSlice(startingHand.InHand, NumericCompare),
+		PlayerId: startingHand.Id,
+		Cards:    []Card{minSlice(startingHand.InHand, NumericCompare)},
 	}
 
 	result := game.PlayHand(play)
@@ -80,12 +83,16 @@ func TestPlayHandSuccess(t *testing.T) {
 		t.Fatalf("Round number mismatch. Expected: 1, actual: %d.", result.Round)
 	}
 
-	if !game.isNextTo(result.NextPlayerId, startingHand.Id) {
+	if result.Burned {
+		if result.NextPlayerId != startingHand.Id {
+			t.Fatalf("Burning player should go again. startingPlayerId: %d, nextPlayerId: %d.", startingHand.Id, result.NextPlayerId)
+		}
+	} else if !game.isNextTo(result.NextPlayerId, startingHand.Id) {
 		t.Fatalf("Next player mismatch. startingPlayerId: %d, nextPlayerId: %d.", startingHand.Id, result.NextPlayerId)
 	}
 
-	if slices.Contains(startingHand.InHand, play.Card) {
-		t.Fatalf("Card should not be found in starting hand. Card: %s, startingHand: %v", play.Card, startingHand.InHand)
+	if slices.Contains(startingHand.InHand, play.Cards[0]) {
+		t.Fatalf("Card should not be found in starting hand. Card: %s, startingHand: %v", play.Cards[0], startingHand.InHand)
 	}
 
 	if len(startingHand.InHand) != 3 {
@@ -95,34 +102,937 @@ func TestPlayHandSuccess(t *testing.T) {
 
 func TestPlayHandFail(t *testing.T) {
 	numOfPlayers := 4
-	game := NewGame(numOfPlayers)
+	game := newGame(t, numOfPlayers, StandardRules)
 	game.Init()
 
 	// Attempt to play a card from the left player's hand, which should fail
-	startingHand := game.CurrentHand()
+	startingHand, ok := game.CurrentHand()
+	if !ok {
+		t.Fatal("Expected a current hand once the game has started.")
+	}
 	handToTheLeft := game.leftOf(startingHand.Id)
 	play := Play{
-		Hand: &game.Hands[handToTheLeft],
-		Card: minSlice(startingHand.InHand, NumericCompare),
+		PlayerId: handToTheLeft,
+		Cards:    []Card{minSlice(startingHand.InHand, NumericCompare)},
 	}
 	result := game.PlayHand(play)
 	if result.Success {
 		t.Fatal("Expected play to fail, but it succeeded.")
 	}
-	if startingHand.Id != game.CurrentHand().Id {
-		t.Fatalf("Current hand should not have changed. Expected: %d, actual: %d.", startingHand.Id, game.CurrentHand().Id)
+	if startingHand.Id != game.currentPlayerId {
+		t.Fatalf("Current hand should not have changed. Expected: %d, actual: %d.", startingHand.Id, game.currentPlayerId)
 	}
 
 	// Attempt to play a card not from the current hand, which should also fail
 	play = Play{
-		Hand: &startingHand,
-		Card: minSlice(game.Hands[handToTheLeft].InHand, NumericCompare),
+		PlayerId: startingHand.Id,
+		Cards:    []Card{minSlice(game.Hands[handToTheLeft].InHand, NumericCompare)},
 	}
 	result = game.PlayHand(play)
-	if result.Success {
-		t.Fatal("Expected play to fail, but it succeeded.")
+	if result.Success || result.Status != Hand_NotInHand {
+		t.Fatalf("Expected play to fail with Hand_NotInHand. Result: %+v.", result)
+	}
+	if startingHand.Id != game.currentPlayerId {
+		t.Fatalf("Current hand should not have changed. Expected: %d, actual: %d.", startingHand.Id, game.currentPlayerId)
+	}
+}
+
+func TestPlayUnknownPlayer(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[4], diamonds[5]},
+		[]Card{spades[5], diamonds[6]},
+	)
+
+	for _, playerId := range []int{-1, 2, SpectatorId} {
+		result := game.PlayHand(Play{PlayerId: playerId, Cards: []Card{clubs[4]}})
+		if result.Success || result.Status != Play_UnknownPlayer {
+			t.Fatalf("Expected play by player %d to fail with Play_UnknownPlayer. Result: %+v.", playerId, result)
+		}
+		if status := game.SwapCards(playerId, clubs[4], diamonds[5]); status != Play_UnknownPlayer {
+			t.Fatalf("Expected swap by player %d to fail with Play_UnknownPlayer. Status: %d.", playerId, status)
+		}
+		if status := game.MarkReady(playerId); status != Play_UnknownPlayer {
+			t.Fatalf("Expected player %d not to be marked ready. Status: %d.", playerId, status)
+		}
+	}
+
+	if len(game.Hands[0].InHand) != 2 || game.round != 0 {
+		t.Fatalf("Rejected plays should not change the game. Hands: %v, round: %d.", game.Hands, game.round)
+	}
+}
+
+func TestPlayHandMultipleCards(t *testing.T) {
+	game := newTestGame(
+		t,
+		[]Card{clubs[9], clubs[10]},
+		[]Card{clubs[4], diamonds[4], hearts[4], clubs[8]},
+		[]Card{spades[5], diamonds[6], hearts[6]},
+	)
+	game.InPlayPile.AddCard(spades[3])
+	startingHand := &game.Hands[0]
+
+	play := Play{
+		PlayerId: startingHand.Id,
+		Cards:    []Card{clubs[4], diamonds[4], hearts[4]},
+	}
+	result := game.PlayHand(play)
+	if !result.Success {
+		t.Fatalf("Expected play to succeed, but it failed. Status: %d.", result.Status)
+	}
+
+	for _, card := range play.Cards {
+		if slices.Contains(startingHand.InHand, card) {
+			t.Fatalf("Card should not be found in starting hand. Card: %s, startingHand: %v", card, startingHand.InHand)
+		}
+	}
+
+	if len(game.InPlayPile.Cards) != 4 {
+		t.Fatalf("In play pile should have 4 cards. Actual: %d.", len(game.InPlayPile.Cards))
+	}
+
+	if len(startingHand.InHand) != 3 {
+		t.Fatalf("Starting hand should be refilled to 3 cards. Actual: %d.", len(startingHand.InHand))
+	}
+
+	if len(game.DrawPile.Cards) != 0 {
+		t.Fatalf("Draw pile should be empty. Actual: %d.", len(game.DrawPile.Cards))
+	}
+}
+
+func TestPlayHandMultipleCardsFail(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[4], diamonds[4], clubs[8]},
+		[]Card{spades[5], diamonds[6], hearts[6]},
+	)
+	startingHand := &game.Hands[0]
+
+	testCases := []struct {
+		cards  []Card
+		status Status
+	}{
+		{[]Card{}, Play_NoCards},
+		{[]Card{clubs[4], clubs[8]}, Play_MixedRanks},
+		{[]Card{clubs[4], clubs[4]}, Play_DuplicateCards},
+		{[]Card{clubs[4], hearts[4]}, Hand_PartiallyOwned},
+		{[]Card{spades[4], hearts[4]}, Hand_NotInHand},
+	}
+
+	for _, testCase := range testCases {
+		result := game.PlayHand(Play{PlayerId: startingHand.Id, Cards: testCase.cards})
+		if result.Success {
+			t.Fatalf("Expected play of %v to fail, but it succeeded.", testCase.cards)
+		}
+		if result.Status != testCase.status {
+			t.Fatalf("Status mismatch for %v. Expected: %d, actual: %d.", testCase.cards, testCase.status, result.Status)
+		}
+		if len(startingHand.InHand) != 3 {
+			t.Fatalf("Starting hand should still have 3 cards. Actual: %d.", len(startingHand.InHand))
+		}
+	}
+}
+
+func newGame(t *testing.T, numOfPlayers int, ruleSet RuleSet) *Game {
+	game, err := NewGame(numOfPlayers, ruleSet)
+	if err != nil {
+		t.Fatalf("Expected game to be created. Error: %s", err)
+	}
+	return game
+}
+
+// Creates a started game where player 0 goes first, the draw pile holds exactly drawPile
+// and every hand only holds the given cards in hand.
+func newTestGame(t *testing.T, drawPile []Card, inHands ...[]Card) *Game {
+	game := newGame(t, len(inHands), StandardRules)
+	game.DrawPile = &Deck{Cards: slices.Clone(drawPile)}
+	for i, inHand := range inHands {
+		game.Hands[i].InHand = slices.Clone(inHand)
+		game.Hands[i].FaceUp = []Card{}
+		game.Hands[i].FaceDown = []Card{}
+	}
+	game.currentPlayerId = 0
+	return game
+}
+
+func TestPlayHandBurnOnTen(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[9], clubs[2]},
+		[]Card{spades[5]},
+	)
+	game.InPlayPile.AddCard(spades[4])
+	game.InPlayPile.AddCard(spades[6])
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[9]}})
+	testBurned(t, game, result, 3)
+}
+
+func TestPlayHandBurnOnFourOfAKind(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[4], diamonds[4], clubs[2]},
+		[]Card{spades[5]},
+	)
+	game.InPlayPile.AddCard(spades[3])
+	game.InPlayPile.AddCard(hearts[4])
+	game.InPlayPile.AddCard(spades[4])
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[4], diamonds[4]}})
+	testBurned(t, game, result, 5)
+}
+
+func TestPlayHandNoBurn(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[4], diamonds[4], clubs[2]},
+		[]Card{spades[5]},
+	)
+	game.InPlayPile.AddCard(hearts[4])
+	game.InPlayPile.AddCard(spades[3])
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[4], diamonds[4]}})
+	if !result.Success || result.Burned {
+		t.Fatalf("Expected play to succeed without burning. Result: %+v.", result)
+	}
+	if result.NextPlayerId != 1 {
+		t.Fatalf("Next player mismatch. Expected: 1, actual: %d.", result.NextPlayerId)
+	}
+	if len(game.DiscardPile.Cards) != 0 {
+		t.Fatalf("Discard pile should be empty. Actual: %v.", game.DiscardPile.Cards)
+	}
+}
+
+func testBurned(t *testing.T, game *Game, result PlayResult, numOfBurnedCards int) {
+	if !result.Success {
+		t.Fatalf("Expected play to succeed, but it failed. Status: %d.", result.Status)
+	}
+	if !result.Burned {
+		t.Fatal("Expected the pile to burn.")
+	}
+	if result.NextPlayerId != 0 {
+		t.Fatalf("Burning player should go again. Expected: 0, actual: %d.", result.NextPlayerId)
+	}
+	if len(game.InPlayPile.Cards) != 0 {
+		t.Fatalf("In play pile should be empty. Actual: %v.", game.InPlayPile.Cards)
+	}
+	if len(game.DiscardPile.Cards) != numOfBurnedCards {
+		t.Fatalf("Discard pile should have %d cards. Actual: %d.", numOfBurnedCards, len(game.DiscardPile.Cards))
+	}
+}
+
+func TestPickUpPile(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[3], clubs[4]},
+		[]Card{spades[5]},
+	)
+	game.InPlayPile.AddCard(spades[6])
+	game.InPlayPile.AddCard(spades[11])
+
+	if game.CanPlay() {
+		t.Fatal("Player should not be able to beat the pile.")
+	}
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[3]}})
+	if result.Success || result.Status != Play_MustPickUp {
+		t.Fatalf("Expected play to fail with Play_MustPickUp. Result: %+v.", result)
+	}
+
+	result = game.PlayHand(Play{Kind: PickUpPile, PlayerId: 0})
+	if !result.Success || !result.PickedUp {
+		t.Fatalf("Expected pick up to succeed. Result: %+v.", result)
+	}
+	if result.NextPlayerId != 1 {
+		t.Fatalf("Next player mismatch. Expected: 1, actual: %d.", result.NextPlayerId)
+	}
+	if len(game.InPlayPile.Cards) != 0 {
+		t.Fatalf("In play pile should be empty. Actual: %v.", game.InPlayPile.Cards)
+	}
+	if !slices.Contains(game.Hands[0].InHand, spades[6]) || !slices.Contains(game.Hands[0].InHand, spades[11]) {
+		t.Fatalf("Hand should contain the picked up cards. Actual: %v.", game.Hands[0].InHand)
+	}
+}
+
+func TestPickUpEmptyPile(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[3], clubs[4]},
+		[]Card{spades[5]},
+	)
+
+	if !game.CanPlay() {
+		t.Fatal("Player should be able to play on an empty pile.")
+	}
+
+	result := game.PlayHand(Play{Kind: PickUpPile, PlayerId: 0})
+	if result.Success || result.Status != Play_PileEmpty {
+		t.Fatalf("Expected pick up to fail with Play_PileEmpty. Result: %+v.", result)
+	}
+}
+
+func TestPlayHandInvisible(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[2], clubs[3], clubs[4]},
+		[]Card{spades[3], spades[10]},
+	)
+	game.InPlayPile.AddCard(hearts[8])
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[2]}})
+	if !result.Success {
+		t.Fatalf("Expected three to be playable on anything. Status: %d.", result.Status)
+	}
+
+	topCard, _ := game.topCard()
+	if topCard != hearts[8] {
+		t.Fatalf("Top card should be the card under the three. Expected: %s, actual: %s.", hearts[8], topCard)
+	}
+
+	result = game.PlayHand(Play{PlayerId: 1, Cards: []Card{spades[3]}})
+	if result.Success || result.Status != Play_CardTooLow {
+		t.Fatalf("Expected play to fail with Play_CardTooLow. Result: %+v.", result)
+	}
+}
+
+func TestPlayHandWithoutRules(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[9], clubs[12]},
+		[]Card{spades[3]},
+	)
+	game.setRuleSet(PlainRules)
+	game.InPlayPile.AddCard(hearts[8])
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[9]}})
+	if !result.Success || result.Burned {
+		t.Fatalf("Expected ten to be played without burning. Result: %+v.", result)
+	}
+}
+
+func TestPlayHandReverse(t *testing.T) {
+	testCases := []struct {
+		numOfPlayers  int
+		afterReverse  int
+		afterNextPlay int
+	}{
+		{2, 1, 0},
+		{3, 2, 1},
+		{6, 5, 4},
+	}
+
+	for _, testCase := range testCases {
+		inHands := make([][]Card, testCase.numOfPlayers)
+		for i := range inHands {
+			inHands[i] = []Card{clubs[7], diamonds[7], hearts[8], spades[12]}
+		}
+		game := newTestGame(t, nil, inHands...)
+		game.setRuleSet(ReverseRules)
+
+		hand := &game.Hands[0]
+		result := game.PlayHand(Play{PlayerId: hand.Id, Cards: hand.InHand[:1]})
+		if !result.Success || !result.Reversed {
+			t.Fatalf("%d players: expected the play to reverse. Result: %+v.", testCase.numOfPlayers, result)
+		}
+		if result.NextPlayerId != testCase.afterReverse {
+			t.Fatalf("%d players: next player mismatch after reverse. Expected: %d, actual: %d.", testCase.numOfPlayers, testCase.afterReverse, result.NextPlayerId)
+		}
+
+		hand = &game.Hands[result.NextPlayerId]
+		result = game.PlayHand(Play{PlayerId: hand.Id, Cards: []Card{hearts[8]}})
+		if !result.Success || result.Reversed {
+			t.Fatalf("%d players: expected the play to succeed without reversing. Result: %+v.", testCase.numOfPlayers, result)
+		}
+		if result.NextPlayerId != testCase.afterNextPlay {
+			t.Fatalf("%d players: next player mismatch. Expected: %d, actual: %d.", testCase.numOfPlayers, testCase.afterNextPlay, result.NextPlayerId)
+		}
+
+		hand = &game.Hands[result.NextPlayerId]
+		result = game.PlayHand(Play{PlayerId: hand.Id, Cards: []Card{spades[12]}})
+		if !result.Success {
+			t.Fatalf("%d players: expected the play to succeed. Result: %+v.", testCase.numOfPlayers, result)
+		}
+		result = game.PlayHand(Play{Kind: PickUpPile, PlayerId: result.NextPlayerId})
+		if !result.Success {
+			t.Fatalf("%d players: expected the pick up to succeed. Result: %+v.", testCase.numOfPlayers, result)
+		}
+
+		hand = &game.Hands[result.NextPlayerId]
+		result = game.PlayHand(Play{PlayerId: hand.Id, Cards: []Card{diamonds[7]}})
+		if !result.Success || !result.Reversed {
+			t.Fatalf("%d players: expected the play to reverse. Result: %+v.", testCase.numOfPlayers, result)
+		}
+		if game.direction != 1 {
+			t.Fatalf("%d players: direction should be back to 1. Actual: %d.", testCase.numOfPlayers, game.direction)
+		}
+		if result.NextPlayerId != game.rightOf(hand.Id) {
+			t.Fatalf("%d players: next player mismatch after second reverse. Expected: %d, actual: %d.", testCase.numOfPlayers, game.rightOf(hand.Id), result.NextPlayerId)
+		}
+	}
+}
+
+func TestSetupPhase(t *testing.T) {
+	numOfPlayers := 3
+	game := newGame(t, numOfPlayers, StandardRules)
+	hand := &game.Hands[0]
+	inHand, faceUp := hand.InHand[0], hand.FaceUp[2]
+
+	status := game.SwapCards(hand.Id, inHand, faceUp)
+	if status != Success {
+		t.Fatalf("Expected swap to succeed. Status: %d.", status)
+	}
+	if hand.InHand[0] != faceUp || hand.FaceUp[2] != inHand {
+		t.Fatalf("Cards were not swapped. InHand: %v, FaceUp: %v.", hand.InHand, hand.FaceUp)
+	}
+
+	status = game.SwapCards(hand.Id, inHand, faceUp)
+	if status != Hand_NotInHand {
+		t.Fatalf("Expected swap of a face up card to fail with Hand_NotInHand. Status: %d.", status)
+	}
+
+	if _, ok := game.CurrentHand(); ok || game.currentPlayerId != NotStartedPlayerId {
+		t.Fatalf("Reading the current hand should not start the game. currentPlayerId: %d.", game.currentPlayerId)
+	}
+
+	for i := 0; i < numOfPlayers-1; i++ {
+		if status := game.MarkReady(i); status != Success {
+			t.Fatalf("Expected hand %d to be marked ready. Status: %d.", i, status)
+		}
+		if game.currentPlayerId != NotStartedPlayerId {
+			t.Fatalf("Game should not start before every hand is ready. currentPlayerId: %d.", game.currentPlayerId)
+		}
+	}
+
+	status = game.SwapCards(hand.Id, hand.InHand[1], hand.FaceUp[1])
+	if status != Setup_AlreadyReady {
+		t.Fatalf("Expected swap after ready to fail with Setup_AlreadyReady. Status: %d.", status)
+	}
+
+	game.MarkReady(numOfPlayers - 1)
+	if game.currentPlayerId == NotStartedPlayerId {
+		t.Fatal("Game should start once every hand is ready.")
+	}
+
+	status = game.SwapCards(numOfPlayers-1, game.Hands[numOfPlayers-1].InHand[0], game.Hands[numOfPlayers-1].FaceUp[0])
+	if status != Setup_GameStarted {
+		t.Fatalf("Expected swap after start to fail with Setup_GameStarted. Status: %d.", status)
+	}
+
+	// Starting again does nothing, so the log still replays
+	startingPlayerId, events := game.currentPlayerId, len(game.EventsSince(0))
+	game.Init()
+	if game.currentPlayerId != startingPlayerId || len(game.EventsSince(0)) != events {
+		t.Fatalf("Init should do nothing once the game has started. currentPlayerId: %d, events: %d.", game.currentPlayerId, len(game.EventsSince(0)))
+	}
+	if _, err := Replay(game.EventsSince(0)); err != nil {
+		t.Fatalf("Expected the log to replay. Error: %s.", err)
+	}
+}
+
+func TestPlayFaceDown(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{},
+		[]Card{spades[5]},
+	)
+	hand := &game.Hands[0]
+	hand.FaceDown = []Card{clubs[4], clubs[12]}
+	game.InPlayPile.AddCard(hearts[8])
+
+	result := game.PlayHand(Play{PlayerId: hand.Id, Cards: []Card{clubs[12]}})
+	if result.Success || result.Status != Hand_FaceDownHidden {
+		t.Fatalf("Expected naming a face down card to fail with Hand_FaceDownHidden. Result: %+v.", result)
+	}
+
+	result = game.PlayHand(Play{Kind: PlayFaceDown, PlayerId: hand.Id, Slot: 2})
+	if result.Success || result.Status != Hand_NoSuchSlot {
+		t.Fatalf("Expected play to fail with Hand_NoSuchSlot. Result: %+v.", result)
+	}
+
+	result = game.PlayHand(Play{Kind: PlayFaceDown, PlayerId: hand.Id, Slot: 1})
+	if !result.Success || result.PickedUp {
+		t.Fatalf("Expected face down play to succeed. Result: %+v.", result)
 	}
-	if startingHand.Id != game.CurrentHand().Id {
-		t.Fatalf("Current hand should not have changed. Expected: %d, actual: %d.", startingHand.Id, game.CurrentHand().Id)
+	if result.Revealed != clubs[12] {
+		t.Fatalf("Revealed card mismatch. Expected: %s, actual: %s.", clubs[12], result.Revealed)
+	}
+	if topCard, _ := game.topCard(); topCard != clubs[12] {
+		t.Fatalf("Revealed card should be on top of the pile. Actual: %s.", topCard)
+	}
+	if !slices.Equal(hand.FaceDown, []Card{clubs[4]}) {
+		t.Fatalf("Face down cards mismatch. Actual: %v.", hand.FaceDown)
+	}
+}
+
+func TestPlayFaceDownPickUp(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{},
+		[]Card{spades[5]},
+	)
+	hand := &game.Hands[0]
+	hand.FaceDown = []Card{clubs[4], clubs[12]}
+	game.InPlayPile.AddCard(hearts[8])
+
+	result := game.PlayHand(Play{Kind: PlayFaceDown, PlayerId: hand.Id, Slot: 0})
+	if !result.Success || !result.PickedUp {
+		t.Fatalf("Expected face down play to end in a pick up. Result: %+v.", result)
+	}
+	if result.Revealed != clubs[4] {
+		t.Fatalf("Revealed card mismatch. Expected: %s, actual: %s.", clubs[4], result.Revealed)
+	}
+	if result.NextPlayerId != 1 {
+		t.Fatalf("Next player mismatch. Expected: 1, actual: %d.", result.NextPlayerId)
+	}
+	if !slices.Contains(hand.InHand, clubs[4]) || !slices.Contains(hand.InHand, hearts[8]) {
+		t.Fatalf("Hand should contain the pile and the revealed card. Actual: %v.", hand.InHand)
+	}
+	if len(game.InPlayPile.Cards) != 0 {
+		t.Fatalf("In play pile should be empty. Actual: %v.", game.InPlayPile.Cards)
+	}
+}
+
+func TestFinishingOrder(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[3]},
+		[]Card{clubs[4], clubs[8]},
+		[]Card{clubs[5], clubs[12]},
+	)
+
+	plays := []struct {
+		playerId     int
+		card         Card
+		nextPlayerId int
+	}{
+		{0, clubs[3], 1},
+		{1, clubs[4], 2},
+		{2, clubs[5], 1},
+		{1, clubs[8], EndedPlayerId},
+	}
+
+	var result PlayResult
+	for _, play := range plays {
+		result = game.PlayHand(Play{PlayerId: play.playerId, Cards: []Card{play.card}})
+		if !result.Success {
+			t.Fatalf("Expected play of %s by %d to succeed. Status: %d.", play.card, play.playerId, result.Status)
+		}
+		if result.NextPlayerId != play.nextPlayerId {
+			t.Fatalf("Next player mismatch after %d played. Expected: %d, actual: %d.", play.playerId, play.nextPlayerId, result.NextPlayerId)
+		}
+	}
+
+	if !result.GameOver || !game.IsOver() {
+		t.Fatal("Expected the game to be over.")
+	}
+	if !slices.Equal(result.Standings, []int{0, 1, 2}) {
+		t.Fatalf("Standings mismatch. Expected: [0 1 2], actual: %v.", result.Standings)
+	}
+	if game.Shithead() != 2 {
+		t.Fatalf("Shithead mismatch. Expected: 2, actual: %d.", game.Shithead())
+	}
+	if hand, ok := game.CurrentHand(); ok {
+		t.Fatalf("Expected no current hand once the game is over. Actual: %v.", hand)
+	}
+
+	result = game.PlayHand(Play{PlayerId: 2, Cards: []Card{clubs[12]}})
+	if result.Success || result.Status != Play_GameOver {
+		t.Fatalf("Expected play to fail with Play_GameOver. Result: %+v.", result)
+	}
+}
+
+func TestFinishOnBurn(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[9]},
+		[]Card{clubs[4]},
+		[]Card{clubs[5]},
+	)
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[9]}})
+	if !result.Success || !result.Burned {
+		t.Fatalf("Expected play to burn. Result: %+v.", result)
+	}
+	if result.NextPlayerId != 1 {
+		t.Fatalf("Finished player should not go again. Expected: 1, actual: %d.", result.NextPlayerId)
+	}
+	if result.GameOver || !slices.Equal(result.Standings, []int{0}) {
+		t.Fatalf("Expected only player 0 to have finished. Result: %+v.", result)
+	}
+}
+
+func TestPlayHandTooLowKeepsCards(t *testing.T) {
+	game := newTestGame(
+		t,
+		[]Card{hearts[4]},
+		[]Card{clubs[4], diamonds[4], clubs[11]},
+		[]Card{spades[5]},
+	)
+	game.InPlayPile.AddCard(hearts[8])
+	hand := &game.Hands[0]
+
+	result := game.PlayHand(Play{PlayerId: hand.Id, Cards: []Card{clubs[4], diamonds[4]}})
+	if result.Success || result.Status != Play_CardTooLow {
+		t.Fatalf("Expected play to fail with Play_CardTooLow. Result: %+v.", result)
+	}
+	if !slices.Equal(hand.InHand, []Card{clubs[4], diamonds[4], clubs[11]}) {
+		t.Fatalf("Rejected play should not change the hand. Actual: %v.", hand.InHand)
+	}
+	if !slices.Equal(game.InPlayPile.Cards, []Card{hearts[8]}) {
+		t.Fatalf("Rejected play should not change the in play pile. Actual: %v.", game.InPlayPile.Cards)
+	}
+	if len(game.DrawPile.Cards) != 1 {
+		t.Fatalf("Rejected play should not draw a card. Draw pile: %v.", game.DrawPile.Cards)
+	}
+	if result.Round != 0 || result.NextPlayerId != 0 {
+		t.Fatalf("Rejected play should not end the turn. Result: %+v.", result)
+	}
+}
+
+func TestValidatePlay(t *testing.T) {
+	game := newTestGame(
+		t,
+		[]Card{hearts[4]},
+		[]Card{clubs[4], clubs[11]},
+		[]Card{spades[5]},
+	)
+	game.InPlayPile.AddCard(hearts[8])
+	hand := &game.Hands[0]
+
+	testCases := []struct {
+		play   Play
+		status Status
+	}{
+		{Play{PlayerId: hand.Id, Cards: []Card{clubs[11]}}, Success},
+		{Play{PlayerId: hand.Id, Cards: []Card{clubs[4]}}, Play_CardTooLow},
+		{Play{PlayerId: 1, Cards: []Card{spades[5]}}, Play_WrongPlayer},
+		{Play{Kind: PickUpPile, PlayerId: hand.Id}, Success},
+		{Play{Kind: PlayFaceDown, PlayerId: hand.Id}, Hand_NotFaceDown},
+	}
+
+	before := game.String()
+	for _, testCase := range testCases {
+		status := game.ValidatePlay(testCase.play)
+		if status != testCase.status {
+			t.Fatalf("Status mismatch for %+v. Expected: %d, actual: %d.", testCase.play, testCase.status, status)
+		}
+	}
+	if game.String() != before {
+		t.Fatalf("ValidatePlay should not change the game.\nBefore:\n%s\nAfter:\n%s", before, game.String())
+	}
+}
+
+func TestLegalPlays(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[4], clubs[8], diamonds[8], hearts[1]},
+		[]Card{spades[5]},
+	)
+	game.InPlayPile.AddCard(hearts[5])
+	hand := &game.Hands[0]
+
+	plays := game.LegalPlays(0)
+	expected := [][]Card{
+		{clubs[8]},
+		{clubs[8], diamonds[8]},
+		{hearts[1]},
+	}
+	if len(plays) != len(expected)+1 {
+		t.Fatalf("Expected %d legal plays. Actual: %+v.", len(expected)+1, plays)
+	}
+	for i, cards := range expected {
+		if plays[i].Kind != PlayCards || !slices.Equal(plays[i].Cards, cards) {
+			t.Fatalf("Legal play %d mismatch. Expected: %v, actual: %+v.", i, cards, plays[i])
+		}
+	}
+	if plays[len(plays)-1].Kind != PickUpPile {
+		t.Fatalf("Expected picking up to be legal. Actual: %+v.", plays[len(plays)-1])
+	}
+
+	for _, play := range plays {
+		if game.ValidatePlay(play) != Success {
+			t.Fatalf("Legal play should validate. Play: %+v.", play)
+		}
+	}
+
+	if len(game.LegalPlays(1)) != 0 {
+		t.Fatalf("Player 1 should not have any legal plays. Actual: %+v.", game.LegalPlays(1))
+	}
+
+	notStarted := newGame(t, 2, StandardRules)
+	if plays := notStarted.LegalPlays(NotStartedPlayerId); len(plays) != 0 {
+		t.Fatalf("There should be no legal plays before the game starts. Actual: %+v.", plays)
+	}
+
+	hand.InHand = []Card{}
+	hand.FaceDown = []Card{clubs[2], clubs[3]}
+	plays = game.LegalPlays(0)
+	if len(plays) != 3 || plays[0].Kind != PlayFaceDown || plays[1].Slot != 1 || plays[2].Kind != PickUpPile {
+		t.Fatalf("Expected both face down slots and picking up to be legal. Actual: %+v.", plays)
+	}
+}
+
+func TestLegalPlaysManyDecks(t *testing.T) {
+	fives := make([]Card, 0, 16)
+	for deck := range uint8(4) {
+		for _, suit := range [][]Card{clubs, diamonds, hearts, spades} {
+			five := suit[4]
+			five.Deck = deck
+			fives = append(fives, five)
+		}
+	}
+	game := newTestGame(t, nil, fives, []Card{spades[5]})
+	game.InPlayPile.AddCard(hearts[3])
+
+	plays := game.LegalPlays(0)
+	if len(plays) != len(fives)+1 {
+		t.Fatalf("Expected one play for each number of fives and picking up. Actual: %d plays.", len(plays))
+	}
+	for i, play := range plays[:len(fives)] {
+		if !slices.Equal(play.Cards, fives[:i+1]) {
+			t.Fatalf("Expected the first %d fives. Actual: %v.", i+1, play.Cards)
+		}
+	}
+}
+
+func TestInitGameAceHigh(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[0], clubs[12], clubs[11]},
+		[]Card{spades[3], spades[8], spades[9]},
+	)
+	game.currentPlayerId = NotStartedPlayerId
+
+	game.Init()
+	if game.currentPlayerId != 1 {
+		t.Fatalf("Player holding an ace should not start with ace high. Actual: %d.", game.currentPlayerId)
+	}
+
+	aceLow := StandardRules
+	aceLow.AceHigh = false
+	game.setRuleSet(aceLow)
+	game.currentPlayerId = NotStartedPlayerId
+	game.Init()
+	if game.currentPlayerId != 0 {
+		t.Fatalf("Player holding an ace should start with ace low. Actual: %d.", game.currentPlayerId)
+	}
+}
+
+func TestWildJokers(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{jokers[0], clubs[4], clubs[12]},
+		[]Card{spades[4], diamonds[4], jokers[1], spades[12]},
+	)
+	game.setRuleSet(WildRules)
+	game.InPlayPile.AddCard(hearts[4])
+	game.InPlayPile.AddCard(hearts[6])
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{jokers[0]}})
+	if !result.Success {
+		t.Fatalf("Expected wild joker to be playable on a seven. Status: %d.", result.Status)
+	}
+	if topCard, _ := game.topCard(); topCard != hearts[6] {
+		t.Fatalf("Joker should mirror the card below it. Expected: %s, actual: %s.", hearts[6], topCard)
+	}
+
+	game.InPlayPile.Cards = []Card{hearts[4]}
+	result = game.PlayHand(Play{PlayerId: 1, Cards: []Card{spades[4], diamonds[4]}})
+	if !result.Success || result.Burned {
+		t.Fatalf("Expected play to succeed without burning. Result: %+v.", result)
+	}
+	result = game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[4]}})
+	if !result.Success || !result.Burned || result.GameOver {
+		t.Fatalf("Expected four fives to burn. Result: %+v.", result)
+	}
+
+	game.InPlayPile.Cards = []Card{hearts[4], spades[4], diamonds[4]}
+	game.currentPlayerId = 1
+	result = game.PlayHand(Play{PlayerId: 1, Cards: []Card{jokers[1]}})
+	if !result.Success || !result.Burned {
+		t.Fatalf("Expected a joker mirroring the fourth five to burn. Result: %+v.", result)
+	}
+}
+
+func TestInitGameWildJokers(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{jokers[0], jokers[1]},
+		[]Card{spades[12], spades[11]},
+	)
+	game.currentPlayerId = NotStartedPlayerId
+
+	game.Init()
+	if game.currentPlayerId != 1 {
+		t.Fatalf("High jokers should not be the lowest card. Actual: %d.", game.currentPlayerId)
+	}
+
+	game.setRuleSet(WildRules)
+	game.Hands[0].InHand = []Card{jokers[0], clubs[12]}
+	game.Hands[1].InHand = []Card{spades[11]}
+	game.currentPlayerId = NotStartedPlayerId
+	game.Init()
+	if game.currentPlayerId != 1 {
+		t.Fatalf("Wild jokers should not be the lowest card. Actual: %d.", game.currentPlayerId)
+	}
+}
+
+func TestInitGameSpecialCards(t *testing.T) {
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[1], clubs[2], clubs[9], clubs[12]},
+		[]Card{spades[3], spades[11]},
+	)
+	game.currentPlayerId = NotStartedPlayerId
+
+	game.Init()
+	if game.currentPlayerId != 1 {
+		t.Fatalf("Cards that go on anything should not be the lowest card. Actual: %d.", game.currentPlayerId)
+	}
+}
+
+func TestGameLargeTable(t *testing.T) {
+	testCases := []struct {
+		numOfPlayers int
+		numOfDecks   int
+	}{
+		{2, 1},
+		{5, 1},
+		{6, 2},
+		{10, 2},
+		{11, 3},
+	}
+
+	for _, testCase := range testCases {
+		game := newGame(t, testCase.numOfPlayers, StandardRules)
+		for _, hand := range game.Hands {
+			if len(hand.FaceDown) != 3 || len(hand.FaceUp) != 3 || len(hand.InHand) != 3 {
+				t.Fatalf("%d players: expected a full hand. Actual: %+v.", testCase.numOfPlayers, hand)
+			}
+		}
+
+		numOfCards := testCase.numOfDecks*len(StandardDeck) - testCase.numOfPlayers*9
+		if len(game.DrawPile.Cards) != numOfCards {
+			t.Fatalf("%d players: expected %d cards in the draw pile. Actual: %d.", testCase.numOfPlayers, numOfCards, len(game.DrawPile.Cards))
+		}
+	}
+}
+
+func TestPlayHandCopiesOfACard(t *testing.T) {
+	copyOfFive := clubs[4]
+	copyOfFive.Deck = 1
+	game := newTestGame(
+		t,
+		nil,
+		[]Card{clubs[4], copyOfFive, clubs[12]},
+		[]Card{spades[5]},
+	)
+
+	result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{clubs[4]}})
+	if !result.Success {
+		t.Fatalf("Expected play to succeed. Status: %d.", result.Status)
+	}
+	if !slices.Equal(game.Hands[0].InHand, []Card{copyOfFive, clubs[12]}) {
+		t.Fatalf("Only the played copy should be removed. Actual: %v.", game.Hands[0].InHand)
+	}
+}
+
+func TestNewGameInvalid(t *testing.T) {
+	twoDecks := StandardRules
+	twoDecks.Decks = 2
+
+	testCases := []struct {
+		numOfPlayers int
+		ruleSet      RuleSet
+		err          error
+	}{
+		{0, StandardRules, ErrTooFewPlayers},
+		{1, StandardRules, ErrTooFewPlayers},
+		{21, StandardRules, ErrTooManyPlayers},
+		{50, StandardRules, ErrTooManyPlayers},
+		{13, twoDecks, ErrTooManyPlayers},
+	}
+
+	for _, testCase := range testCases {
+		game, err := NewGame(testCase.numOfPlayers, testCase.ruleSet)
+		if game != nil || !errors.Is(err, testCase.err) {
+			t.Fatalf("%d players: expected error %q. Actual: %v.", testCase.numOfPlayers, testCase.err, err)
+		}
+		t.Log(err)
+	}
+
+	invalid := StandardRules
+	invalid.InHandCards = -1
+	if _, err := NewGame(4, invalid); err == nil {
+		t.Fatal("Expected invalid rule set to be rejected.")
+	}
+
+	if _, err := NewGame(12, twoDecks); err != nil {
+		t.Fatalf("Expected 12 players to fit in two decks. Error: %s", err)
+	}
+	if _, err := NewGame(20, StandardRules); err != nil {
+		t.Fatalf("Expected 20 players to fit in %d decks. Error: %s", MaxDecks, err)
+	}
+}
+
+func TestSeededGame(t *testing.T) {
+	var seed uint64 = 20240518
+	random := ReverseRules
+	random.StartingPlayer = StartingPlayer_Random
+
+	game := newSeededGame(t, 4, random, seed)
+	same := newSeededGame(t, 4, random, seed)
+	if game.Seed != seed {
+		t.Fatalf("Seed mismatch. Expected: %d, actual: %d.", seed, game.Seed)
+	}
+
+	for _, g := range []*Game{game, same} {
+		for i := range g.Hands {
+			g.MarkReady(i)
+		}
+		playOut(g, 200)
+	}
+
+	if game.String() != same.String() {
+		t.Fatalf("Games with the same seed and plays should match.\n%s\n%s", game, same)
+	}
+
+	different := newSeededGame(t, 4, random, seed+1)
+	if slices.Equal(game.DrawPile.Cards, different.DrawPile.Cards) {
+		t.Fatal("Games with different seeds should not be dealt the same cards.")
+	}
+}
+
+func newSeededGame(t *testing.T, numOfPlayers int, ruleSet RuleSet, seed uint64) *Game {
+	game, err := NewSeededGame(numOfPlayers, ruleSet, seed)
+	if err != nil {
+		t.Fatalf("Expected game to be created. Error: %s", err)
+	}
+	return game
+}
+
+// Makes the first legal play until the game is over or the number of plays runs out.
+func playOut(game *Game, numOfPlays int) {
+	for i := 0; i < numOfPlays && !game.IsOver(); i++ {
+		plays := game.LegalPlays(game.currentPlayerId)
+		game.PlayHand(plays[0])
 	}
 }
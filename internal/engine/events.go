@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+type EventType string
+
+const (
+	// The game was created. Seed, Rules and NumOfPlayers are set.
+	Event_Create EventType = "create"
+	// A hand was dealt the Cards into the Zone.
+	Event_Deal EventType = "deal"
+	// The player swapped Cards[0] from their hand with Cards[1] from their face up cards.
+	Event_Swap EventType = "swap"
+	// The player finished the setup phase.
+	Event_Ready EventType = "ready"
+	// The game started with the player.
+	Event_Start EventType = "start"
+	// The player made a play of Kind with the Cards or the face down Slot.
+	Event_Play EventType = "play"
+	// The face down card the player flipped was Cards[0].
+	Event_Reveal EventType = "reveal"
+	// The player drew the Cards from the draw pile.
+	Event_Draw EventType = "draw"
+	// The player took the Cards from the in play pile into their hand.
+	Event_PickUp EventType = "pickUp"
+	// The Cards in the in play pile were burned.
+	Event_Burn EventType = "burn"
+	// The direction of play changed to Direction.
+	Event_Reverse EventType = "reverse"
+	// The player has no cards left.
+	Event_Finish EventType = "finish"
+	// The game is over with the player as the shithead. Standings are set.
+	Event_GameOver EventType = "gameOver"
+	// The last play was taken back and it is the player's turn again.
+	// Like redo, it doesn't say which cards moved. Readers have to replay the log, or read the state afterwards, to know.
+	Event_Undo EventType = "undo"
+	// The last play that was taken back was made again, draws, burns and reveals included, and it is the player's turn.
+	Event_Redo EventType = "redo"
+)
+
+type Zone string
+
+const (
+	Zone_InHand   Zone = "inHand"
+	Zone_FaceUp   Zone = "faceUp"
+	Zone_FaceDown Zone = "faceDown"
+)
+
+// Event is a single change to a game. Only the fields that apply to its Type are set.
+// PlayerId is NotStartedPlayerId for events that aren't about a player.
+type Event struct {
+	Seq          int       `json:"seq"`
+	Type         EventType `json:"type"`
+	PlayerId     int       `json:"playerId"`
+	Kind         PlayKind  `json:"kind,omitempty"`
+	Cards        []Card    `json:"cards,omitempty"`
+	Slot         int       `json:"slot,omitempty"`
+	Zone         Zone      `json:"zone,omitempty"`
+	Direction    int       `json:"direction,omitempty"`
+	Standings    []int     `json:"standings,omitempty"`
+	Seed         uint64    `json:"seed,omitempty"`
+	Rules        *RuleSet  `json:"rules,omitempty"`
+	NumOfPlayers int       `json:"numOfPlayers,omitempty"`
+}
+
+// Appends the event to the game's log.
+func (game *Game) emit(event Event) {
+	event.Seq = len(game.log)
+	game.log = append(game.log, event)
+}
+
+// Returns every event in the game's log, oldest first.
+func (game *Game) Events() []Event {
+	return game.EventsSince(0)
+}
+
+// Returns the events from seq onwards, for catching up on what happened since then.
+func (game *Game) EventsSince(seq int) []Event {
+	if seq < 0 || seq >= len(game.log) {
+		return []Event{}
+	}
+
+	events := make([]Event, 0, len(game.log)-seq)
+	for _, event := range game.log[seq:] {
+		events = append(events, event.clone())
+	}
+	return events
+}
+
+// Rebuilds a game from its log. The create event sets up the game from its seed and the players' own actions
+// are made again. Everything else follows from those, so the rebuilt log has to match the one given.
+// A log may stop anywhere, even part way through the events that follow from an action. The rebuilt game
+// then has all of them, so its log carries on past the end of the one given.
+func Replay(events []Event) (*Game, error) {
+	if len(events) == 0 || events[0].Type != Event_Create || events[0].Rules == nil {
+		return nil, fmt.Errorf("Log does not start with a create event")
+	}
+
+	create := events[0]
+	game, err := NewSeededGame(create.NumOfPlayers, *create.Rules, create.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events[1:] {
+		if err := game.replay(event); err != nil {
+			return nil, fmt.Errorf("Event %d: %w", event.Seq, err)
+		}
+	}
+
+	expected, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	if len(game.log) < len(events) {
+		return nil, fmt.Errorf("Replayed log is shorter than the given log")
+	}
+	actual, err := json.Marshal(game.log[:len(events)])
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected, actual) {
+		return nil, fmt.Errorf("Replayed log does not match the given log")
+	}
+	return game, nil
+}
+
+func (game *Game) replay(event Event) error {
+	if event.PlayerId >= len(game.Hands) {
+		return fmt.Errorf("Unknown player %d", event.PlayerId)
+	}
+
+	status := Success
+	switch event.Type {
+	case Event_Swap:
+		if len(event.Cards) != 2 || event.PlayerId < 0 {
+			return fmt.Errorf("Invalid swap")
+		}
+		status = game.SwapCards(event.PlayerId, event.Cards[0], event.Cards[1])
+	case Event_Ready:
+		if event.PlayerId < 0 {
+			return fmt.Errorf("Invalid ready")
+		}
+		status = game.MarkReady(event.PlayerId)
+	case Event_Start:
+		// Does nothing if marking the last hand ready has already started the game
+		game.Init()
+	case Event_Play:
+		if event.PlayerId < 0 {
+			return fmt.Errorf("Invalid play")
+		}
+		result := game.PlayHand(Play{
+			Kind:     event.Kind,
+			PlayerId: event.PlayerId,
+			Cards:    slices.Clone(event.Cards),
+			Slot:     event.Slot,
+		})
+		status = result.Status
+	case Event_Undo:
+		status = game.Undo()
+	case Event_Redo:
+		status = game.Redo()
+	}
+
+	if status != Success {
+		return fmt.Errorf("%s was rejected with status %d", event.Type, status)
+	}
+	return nil
+}
+
+func (event Event) clone() Event {
+	event.Cards = slices.Clone(event.Cards)
+	event.Standings = slices.Clone(event.Standings)
+	if event.Rules != nil {
+		rules := event.Rules.clone()
+		event.Rules = &rules
+	}
+	return event
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestEventLog(t *testing.T) {
+	game := newSeededGame(t, 3, ReverseRules, 11)
+	game.SwapCards(1, game.Hands[1].InHand[0], game.Hands[1].FaceUp[0])
+	for i := range game.Hands {
+		game.MarkReady(i)
+	}
+	playOut(game, 1000)
+	if !game.IsOver() {
+		t.Fatal("Expected the game to be over.")
+	}
+
+	events := game.Events()
+	types := make([]EventType, 0, len(events))
+	for i, event := range events {
+		if event.Seq != i {
+			t.Fatalf("Event sequence mismatch. Expected: %d, actual: %d.", i, event.Seq)
+		}
+		types = append(types, event.Type)
+	}
+
+	expected := []EventType{Event_Create, Event_Deal, Event_Swap, Event_Ready, Event_Start, Event_Play, Event_Draw, Event_PickUp, Event_Burn, Event_Finish, Event_GameOver}
+	for _, eventType := range expected {
+		if !slices.Contains(types, eventType) {
+			t.Errorf("Expected the log to contain a %s event.", eventType)
+		}
+	}
+	if types[len(types)-1] != Event_GameOver {
+		t.Fatalf("Expected the log to end with the game being over. Actual: %s.", types[len(types)-1])
+	}
+
+	since := game.EventsSince(len(events) - 2)
+	if len(since) != 2 || since[1].Type != Event_GameOver {
+		t.Fatalf("Expected the last two events. Actual: %+v.", since)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	game := newSeededGame(t, 4, WildRules, 3)
+	game.SwapCards(2, game.Hands[2].InHand[1], game.Hands[2].FaceUp[2])
+	for i := range game.Hands {
+		game.MarkReady(i)
+	}
+	playOut(game, 1000)
+
+	data, err := json.Marshal(game.Events())
+	if err != nil {
+		t.Fatalf("Expected the log to encode. Error: %s", err)
+	}
+	var events []Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		t.Fatalf("Expected the log to decode. Error: %s", err)
+	}
+
+	replayed, err := Replay(events)
+	if err != nil {
+		t.Fatalf("Expected the log to replay. Error: %s", err)
+	}
+	if game.String() != replayed.String() {
+		t.Fatalf("Replayed game mismatch.\n%s\n%s", game, replayed)
+	}
+
+	// Catching up from any point in the game, including part way through what follows from a play
+	for cut := 1; cut <= len(events); cut++ {
+		partial, err := Replay(events[:cut])
+		if err != nil {
+			t.Fatalf("Expected the first %d events to replay. Error: %s", cut, err)
+		}
+		partialEvents := partial.Events()
+		if len(partialEvents) < cut || partialEvents[cut-1].Seq != events[cut-1].Seq {
+			t.Fatalf("Partial replay of %d events should contain them. Actual: %d events.", cut, len(partialEvents))
+		}
+		// Only what follows from the last action is added
+		for _, event := range partialEvents[cut:] {
+			if event.Type == Event_Play || event.Type == Event_Swap || event.Type == Event_Undo || event.Type == Event_Redo {
+				t.Fatalf("Partial replay of %d events should not make further actions. Actual: %+v.", cut, event)
+			}
+		}
+	}
+}
+
+func TestReplayEveryPrefix(t *testing.T) {
+	game := newSeededGame(t, 3, StandardRules, 3)
+	game.Init()
+	playOut(game, 1000)
+	events := game.Events()
+
+	for cut := 1; cut <= len(events); cut++ {
+		if _, err := Replay(events[:cut]); err != nil {
+			t.Fatalf("Expected the first %d of %d events to replay. Error: %s", cut, len(events), err)
+		}
+	}
+}
+
+func TestReplayTampered(t *testing.T) {
+	game := newSeededGame(t, 2, StandardRules, 5)
+	game.Init()
+	playOut(game, 10)
+	events := game.Events()
+
+	if _, err := Replay(events[1:]); err == nil {
+		t.Fatal("Expected a log without a create event to be rejected.")
+	}
+
+	extended := append(game.Events(), Event{Seq: len(events), Type: Event_Burn, PlayerId: 0})
+	if _, err := Replay(extended); err == nil {
+		t.Fatal("Expected a log with an event that didn't happen to be rejected.")
+	}
+
+	tampered := game.Events()
+	tampered[1].Cards[0] = ErrorCard
+	if _, err := Replay(tampered); err == nil {
+		t.Fatal("Expected a log with a changed deal to be rejected.")
+	}
+
+	tampered = game.Events()
+	for i, event := range tampered {
+		if event.Type == Event_Play {
+			tampered[i].PlayerId = (event.PlayerId + 1) % 2
+			break
+		}
+	}
+	if _, err := Replay(tampered); err == nil {
+		t.Fatal("Expected a log with a play by the wrong player to be rejected.")
+	}
+}
@@ -3,14 +3,23 @@ package engine
 import "slices"
 
 type Hand struct {
-	Id       int
-	InHand   []Card
-	FaceUp   []Card
-	FaceDown []Card
+	Id       int    `json:"id"`
+	InHand   []Card `json:"inHand"`
+	FaceUp   []Card `json:"faceUp"`
+	FaceDown []Card `json:"faceDown"`
+	Ready    bool   `json:"ready"`
 }
 
 type handResult int
 
+// Returns a copy of the hand that shares no cards with it.
+func (hand Hand) clone() Hand {
+	hand.InHand = slices.Clone(hand.InHand)
+	hand.FaceUp = slices.Clone(hand.FaceUp)
+	hand.FaceDown = slices.Clone(hand.FaceDown)
+	return hand
+}
+
 func (hand *Hand) dealFaceDown(card Card) {
 	hand.FaceDown = append(hand.FaceDown, card)
 }
@@ -23,36 +32,103 @@ func (hand *Hand) dealInHand(card Card) {
 	hand.InHand = append(hand.InHand, card)
 }
 
-func (hand *Hand) removeCard(card Card) Status {
+// Exchanges a card in hand with a face up card, keeping both in the same position.
+func (hand *Hand) swap(inHand Card, faceUp Card) Status {
+	i := slices.Index(hand.InHand, inHand)
+	if i == -1 {
+		return Hand_NotInHand
+	}
+	j := slices.Index(hand.FaceUp, faceUp)
+	if j == -1 {
+		return Hand_NotFaceUp
+	}
+
+	hand.InHand[i], hand.FaceUp[j] = faceUp, inHand
+	return Success
+}
+
+func (hand *Hand) isEmpty() bool {
+	return len(hand.InHand) == 0 && len(hand.FaceUp) == 0 && len(hand.FaceDown) == 0
+}
+
+// Returns the zone the hand is currently playing from, which is InHand, then FaceUp and finally FaceDown.
+func (hand *Hand) activeZone() []Card {
 	if len(hand.InHand) != 0 {
-		if !slices.Contains(hand.InHand, card) {
-			return Hand_NotInHand
-		}
-		hand.InHand = slices.DeleteFunc(hand.InHand, func(c Card) bool {
-			return c == card
-		})
-		return Success
+		return hand.InHand
+	}
+	if len(hand.FaceUp) != 0 {
+		return hand.FaceUp
+	}
+	return hand.FaceDown
+}
+
+// Checks the cards are all in the zone currently in play, which is InHand and then FaceUp.
+// Face down cards are unknown to the player, so they can't be named and have to be taken by slot.
+func (hand *Hand) hasCards(cards ...Card) Status {
+	if len(hand.InHand) != 0 {
+		return zoneHasCards(hand.InHand, cards, Hand_NotInHand)
 	}
 
 	if len(hand.FaceUp) != 0 {
-		if !slices.Contains(hand.FaceUp, card) {
-			return Hand_NotFaceUp
-		}
-		hand.FaceUp = slices.DeleteFunc(hand.FaceUp, func(c Card) bool {
-			return c == card
-		})
-		return Success
+		return zoneHasCards(hand.FaceUp, cards, Hand_NotFaceUp)
 	}
 
 	if len(hand.FaceDown) != 0 {
-		if !slices.Contains(hand.FaceDown, card) {
-			return Hand_NotFaceDown
-		}
-		hand.FaceDown = slices.DeleteFunc(hand.FaceDown, func(c Card) bool {
-			return c == card
-		})
-		return Success
+		return Hand_FaceDownHidden
 	}
 
 	return Hand_NotFound
 }
+
+func zoneHasCards(zone []Card, cards []Card, notFound Status) Status {
+	found := 0
+	for _, card := range cards {
+		if slices.Contains(zone, card) {
+			found++
+		}
+	}
+	if found == 0 {
+		return notFound
+	}
+	if found != len(cards) {
+		return Hand_PartiallyOwned
+	}
+	return Success
+}
+
+// Removes the cards from the zone currently in play.
+// Either every card is removed or, if any of them isn't in that zone, none are.
+func (hand *Hand) removeCard(cards ...Card) Status {
+	status := hand.hasCards(cards...)
+	if status != Success {
+		return status
+	}
+
+	isPlayed := func(c Card) bool {
+		return slices.Contains(cards, c)
+	}
+	if len(hand.InHand) != 0 {
+		hand.InHand = slices.DeleteFunc(hand.InHand, isPlayed)
+	} else {
+		hand.FaceUp = slices.DeleteFunc(hand.FaceUp, isPlayed)
+	}
+	return Success
+}
+
+// Checks the slot can be played. Face down cards are only played once InHand and FaceUp are empty.
+func (hand *Hand) checkFaceDown(slot int) Status {
+	if len(hand.InHand) != 0 || len(hand.FaceUp) != 0 {
+		return Hand_NotFaceDown
+	}
+	if slot < 0 || slot >= len(hand.FaceDown) {
+		return Hand_NoSuchSlot
+	}
+	return Success
+}
+
+// Removes and returns the face down card in the slot, which has already been checked.
+func (hand *Hand) takeFaceDown(slot int) Card {
+	card := hand.FaceDown[slot]
+	hand.FaceDown = slices.Delete(hand.FaceDown, slot, slot+1)
+	return card
+}
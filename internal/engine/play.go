@@ -1,21 +1,48 @@
 package engine
 
+type PlayKind int
+
+const (
+	PlayCards    PlayKind = 0
+	PickUpPile   PlayKind = 1
+	PlayFaceDown PlayKind = 2
+)
+
+// A play names the player making it and the engine looks up that player's hand itself.
+// Cards is used when playing cards from InHand or FaceUp. Face down cards are played blind by their Slot.
 type Play struct {
-	Hand *Hand
-	Card Card
+	Kind     PlayKind
+	PlayerId int
+	Cards    []Card
+	Slot     int
 }
 
 type Status int
 
 const (
-	Success          Status = 0
-	Error            Status = 1
-	Play_WrongPlayer Status = 101
-	Play_CardTooLow  Status = 102
-	Hand_NotFound    Status = 201
-	Hand_NotInHand   Status = 202
-	Hand_NotFaceUp   Status = 203
-	Hand_NotFaceDown Status = 204
+	Success             Status = 0
+	Error               Status = 1
+	Play_WrongPlayer    Status = 101
+	Play_CardTooLow     Status = 102
+	Play_NoCards        Status = 103
+	Play_MixedRanks     Status = 104
+	Play_DuplicateCards Status = 105
+	Play_PileEmpty      Status = 106
+	Play_MustPickUp     Status = 107
+	Play_GameOver       Status = 108
+	Play_UnknownPlayer  Status = 109
+	Hand_NotFound       Status = 201
+	Hand_NotInHand      Status = 202
+	Hand_NotFaceUp      Status = 203
+	Hand_NotFaceDown    Status = 204
+	Hand_PartiallyOwned Status = 205
+	Hand_FaceDownHidden Status = 206
+	Hand_NoSuchSlot     Status = 207
+	Setup_GameStarted   Status = 301
+	Setup_AlreadyReady  Status = 302
+	Undo_Disabled       Status = 401
+	Undo_NothingToUndo  Status = 402
+	Undo_NothingToRedo  Status = 403
 )
 
 type PlayResult struct {
@@ -23,4 +50,31 @@ type PlayResult struct {
 	Success      bool
 	Status       Status
 	NextPlayerId int
+	Burned       bool
+	PickedUp     bool
+	Reversed     bool
+	Revealed     Card
+	GameOver     bool
+	Standings    []int
+}
+
+// Checks that the cards of a play can be played together, i.e. there is at least one card,
+// every card has the same rank and no card is repeated.
+func (play Play) validateCards() Status {
+	if len(play.Cards) == 0 {
+		return Play_NoCards
+	}
+
+	rank := play.Cards[0].Rank
+	for i, card := range play.Cards {
+		if card.Rank != rank {
+			return Play_MixedRanks
+		}
+		for _, other := range play.Cards[i+1:] {
+			if card == other {
+				return Play_DuplicateCards
+			}
+		}
+	}
+	return Success
 }
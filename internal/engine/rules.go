@@ -0,0 +1,159 @@
+package engine
+
+import "slices"
+
+// Effect is what a special card does to the game once it has been played.
+type Effect uint8
+
+const (
+	NoEffect         Effect = 0
+	Effect_Burn      Effect = 1 << 0
+	Effect_Invisible Effect = 1 << 1
+	Effect_Reverse   Effect = 1 << 2
+	Effect_Mirror    Effect = 1 << 3
+)
+
+// Rule turns a rank into a special card. Its compare function is a link in the game's comparator
+// chain, where a is the card being played and b is the top of the in play pile.
+type Rule struct {
+	Rank        Rank
+	Effect      Effect
+	compareFunc cardComparatorFunc
+	// Set for rules that decide every comparison they see. They are chained after the rest,
+	// so cards that go on anything still do, whatever order the rules are listed in.
+	decisive bool
+	// Set for rules that let the card be played on anything.
+	anywhere bool
+}
+
+// The card can be played on anything and anything can be played on it.
+func ResetRule(rank Rank) Rule {
+	return Rule{
+		Rank:   rank,
+		Effect: NoEffect,
+		compareFunc: func(a, b Card) (int, comparatorState) {
+			if a.Rank == rank || b.Rank == rank {
+				return 1, _terminate
+			}
+			return 0, _continue
+		},
+		anywhere: true,
+	}
+}
+
+// The card on top of it has to be of the same rank or lower.
+func LowerRule(rank Rank) Rule {
+	return lowerRule(rank, NumericCompare)
+}
+
+// Like LowerRule, with lower decided by the ranking.
+func lowerRule(rank Rank, ranking CardCompare) Rule {
+	return Rule{
+		Rank:   rank,
+		Effect: NoEffect,
+		compareFunc: func(a, b Card) (int, comparatorState) {
+			if b.Rank == rank {
+				return -ranking(a, b), _terminate
+			}
+			return 0, _continue
+		},
+		decisive: true,
+	}
+}
+
+// The card can be played on anything and the next card is played against the card underneath it.
+func InvisibleRule(rank Rank) Rule {
+	return Rule{
+		Rank:        rank,
+		Effect:      Effect_Invisible,
+		compareFunc: playsOnAnything(rank),
+		anywhere:    true,
+	}
+}
+
+// The card can be played on anything and burns the in play pile.
+func BurnRule(rank Rank) Rule {
+	return Rule{
+		Rank:        rank,
+		Effect:      Effect_Burn,
+		compareFunc: playsOnAnything(rank),
+		anywhere:    true,
+	}
+}
+
+// The card follows the usual ordering and reverses the direction of play. 8 and Queen are common choices.
+func ReverseRule(rank Rank) Rule {
+	return Rule{
+		Rank:   rank,
+		Effect: Effect_Reverse,
+	}
+}
+
+// The card can be played on anything and takes on the rank of the card below it.
+func MirrorRule(rank Rank) Rule {
+	return Rule{
+		Rank:        rank,
+		Effect:      Effect_Mirror,
+		compareFunc: playsOnAnything(rank),
+		anywhere:    true,
+	}
+}
+
+// Ranks the Ace above the King. It decides every comparison that reaches it, so it belongs at the end of the chain.
+func AceHighRule() Rule {
+	return Rule{
+		Rank:   Ace,
+		Effect: NoEffect,
+		compareFunc: func(a, b Card) (int, comparatorState) {
+			return AceHighCompare(a, b), _terminate
+		},
+		decisive: true,
+	}
+}
+
+func playsOnAnything(rank Rank) cardComparatorFunc {
+	return func(a, b Card) (int, comparatorState) {
+		if a.Rank == rank {
+			return 1, _terminate
+		}
+		return 0, _continue
+	}
+}
+
+// The game keeps its own copy of the rule set, so changing the caller's copy can't change the game.
+func (game *Game) setRuleSet(ruleSet RuleSet) {
+	game.ruleSet = ruleSet.clone()
+	game.rules = ruleSet.rules()
+	game.comparator = newRuleComparator(game.rules)
+}
+
+// Chains the rules in order, except that decisive rules go after all the others.
+func newRuleComparator(rules []Rule) CardComparator {
+	compareFuncs := make([]cardComparatorFunc, 0, len(rules))
+	for _, decisive := range []bool{false, true} {
+		for _, rule := range rules {
+			if rule.compareFunc != nil && rule.decisive == decisive {
+				compareFuncs = append(compareFuncs, rule.compareFunc)
+			}
+		}
+	}
+	return newGameComparator(compareFuncs...)
+}
+
+// Combines the effects of every rule for the card's rank.
+func (game *Game) effectOf(card Card) Effect {
+	effect := NoEffect
+	for _, rule := range game.rules {
+		if rule.Rank == card.Rank {
+			effect |= rule.Effect
+		}
+	}
+	return effect
+}
+
+// Whether one of the rules lets the card be played on anything.
+func (game *Game) playsAnywhere(card Card) bool {
+	return slices.ContainsFunc(game.rules, func(rule Rule) bool {
+		return rule.Rank == card.Rank && rule.anywhere
+	})
+}
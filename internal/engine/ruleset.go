@@ -0,0 +1,332 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Power is what a special card does.
+type Power string
+
+const (
+	Power_Reset     Power = "reset"
+	Power_Lower     Power = "lower"
+	Power_Invisible Power = "invisible"
+	Power_Burn      Power = "burn"
+	Power_Reverse   Power = "reverse"
+	Power_Mirror    Power = "mirror"
+)
+
+var powerRules = map[Power]func(rank Rank) Rule{
+	Power_Reset:     ResetRule,
+	Power_Lower:     LowerRule,
+	Power_Invisible: InvisibleRule,
+	Power_Burn:      BurnRule,
+	Power_Reverse:   ReverseRule,
+	Power_Mirror:    MirrorRule,
+}
+
+type SpecialCard struct {
+	Rank  Rank  `json:"rank"`
+	Power Power `json:"power"`
+}
+
+// JokerMode decides whether jokers are in the deck and how they play.
+type JokerMode string
+
+const (
+	// Jokers are left out of the deck.
+	JokerMode_None JokerMode = "none"
+	// Jokers rank above every other card.
+	JokerMode_High JokerMode = "high"
+	// Jokers go on anything and mirror the card below them.
+	JokerMode_Wild JokerMode = "wild"
+)
+
+// StartingPlayer decides who plays first once every hand is ready.
+type StartingPlayer string
+
+const (
+	StartingPlayer_LowestCard StartingPlayer = "lowestCard"
+	StartingPlayer_First      StartingPlayer = "first"
+	StartingPlayer_Random     StartingPlayer = "random"
+)
+
+// RuleSet configures a game. Special cards are turned into comparator rules and can be listed in any order.
+// Undo lets plays be taken back, which competitive games should leave off.
+type RuleSet struct {
+	Name           string         `json:"name"`
+	FaceDownCards  int            `json:"faceDownCards"`
+	FaceUpCards    int            `json:"faceUpCards"`
+	InHandCards    int            `json:"inHandCards"`
+	MinHandSize    int            `json:"minHandSize"`
+	Decks          int            `json:"decks"`
+	Jokers         JokerMode      `json:"jokers"`
+	AceHigh        bool           `json:"aceHigh"`
+	SpecialCards   []SpecialCard  `json:"specialCards"`
+	StartingPlayer StartingPlayer `json:"startingPlayer"`
+	Undo           bool           `json:"undo"`
+}
+
+// The usual special cards: 2 resets, 3 is invisible, 10 burns and 7 forces the next card to be 7 or lower.
+var StandardRules RuleSet = RuleSet{
+	Name:          "standard",
+	FaceDownCards: 3,
+	FaceUpCards:   3,
+	InHandCards:   3,
+	MinHandSize:   3,
+	Jokers:        JokerMode_High,
+	AceHigh:       true,
+	SpecialCards: []SpecialCard{
+		{Two, Power_Reset},
+		{Three, Power_Invisible},
+		{Ten, Power_Burn},
+		{Seven, Power_Lower},
+	},
+	StartingPlayer: StartingPlayer_LowestCard,
+}
+
+// The basic game described on pagat.com: 2 resets and 10 burns, without jokers.
+var PagatRules RuleSet = RuleSet{
+	Name:          "pagat",
+	FaceDownCards: 3,
+	FaceUpCards:   3,
+	InHandCards:   3,
+	MinHandSize:   3,
+	Jokers:        JokerMode_None,
+	AceHigh:       true,
+	SpecialCards: []SpecialCard{
+		{Two, Power_Reset},
+		{Ten, Power_Burn},
+	},
+	StartingPlayer: StartingPlayer_LowestCard,
+}
+
+// The standard rules with 8 reversing the direction of play.
+var ReverseRules RuleSet = RuleSet{
+	Name:          "reverse",
+	FaceDownCards: 3,
+	FaceUpCards:   3,
+	InHandCards:   3,
+	MinHandSize:   3,
+	Jokers:        JokerMode_High,
+	AceHigh:       true,
+	SpecialCards: []SpecialCard{
+		{Two, Power_Reset},
+		{Three, Power_Invisible},
+		{Ten, Power_Burn},
+		{Seven, Power_Lower},
+		{Eight, Power_Reverse},
+	},
+	StartingPlayer: StartingPlayer_LowestCard,
+}
+
+// The standard rules with jokers that go on anything and mirror the card below them.
+var WildRules RuleSet = RuleSet{
+	Name:          "wild",
+	FaceDownCards: 3,
+	FaceUpCards:   3,
+	InHandCards:   3,
+	MinHandSize:   3,
+	Jokers:        JokerMode_Wild,
+	AceHigh:       true,
+	SpecialCards: []SpecialCard{
+		{Two, Power_Reset},
+		{Three, Power_Invisible},
+		{Ten, Power_Burn},
+		{Seven, Power_Lower},
+	},
+	StartingPlayer: StartingPlayer_LowestCard,
+}
+
+// The standard rules with plays that can be taken back, for hot seat and practice tables.
+var PracticeRules RuleSet = RuleSet{
+	Name:          "practice",
+	FaceDownCards: 3,
+	FaceUpCards:   3,
+	InHandCards:   3,
+	MinHandSize:   3,
+	Jokers:        JokerMode_High,
+	AceHigh:       true,
+	SpecialCards: []SpecialCard{
+		{Two, Power_Reset},
+		{Three, Power_Invisible},
+		{Ten, Power_Burn},
+		{Seven, Power_Lower},
+	},
+	StartingPlayer: StartingPlayer_LowestCard,
+	Undo:           true,
+}
+
+// Every card plays by its rank alone.
+var PlainRules RuleSet = RuleSet{
+	Name:           "plain",
+	FaceDownCards:  3,
+	FaceUpCards:    3,
+	InHandCards:    3,
+	MinHandSize:    3,
+	Jokers:         JokerMode_High,
+	AceHigh:        true,
+	SpecialCards:   []SpecialCard{},
+	StartingPlayer: StartingPlayer_LowestCard,
+}
+
+// House rule presets that ship with the package, by name.
+var Presets = map[string]RuleSet{
+	StandardRules.Name: StandardRules,
+	PagatRules.Name:    PagatRules,
+	ReverseRules.Name:  ReverseRules,
+	WildRules.Name:     WildRules,
+	PracticeRules.Name: PracticeRules,
+	PlainRules.Name:    PlainRules,
+}
+
+// Reads a rule set from JSON. Fields that are left out keep their value from StandardRules.
+func LoadRuleSet(r io.Reader) (RuleSet, error) {
+	ruleSet := StandardRules.clone()
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&ruleSet); err != nil {
+		return RuleSet{}, fmt.Errorf("Invalid rule set: %w", err)
+	}
+	if err := ruleSet.Validate(); err != nil {
+		return RuleSet{}, err
+	}
+	return ruleSet, nil
+}
+
+// Checks the rule set describes a playable game.
+func (ruleSet RuleSet) Validate() error {
+	if ruleSet.FaceDownCards < 0 || ruleSet.FaceUpCards < 0 || ruleSet.InHandCards < 0 || ruleSet.MinHandSize < 0 || ruleSet.Decks < 0 {
+		return fmt.Errorf("Rule set %q has a negative number of cards", ruleSet.Name)
+	}
+	if ruleSet.cardsPerPlayer() == 0 {
+		return fmt.Errorf("Rule set %q deals no cards", ruleSet.Name)
+	}
+	if ruleSet.Decks > MaxDecks {
+		return fmt.Errorf("Rule set %q uses %d decks, but at most %d are allowed", ruleSet.Name, ruleSet.Decks, MaxDecks)
+	}
+
+	for _, specialCard := range ruleSet.SpecialCards {
+		if _, ok := powerRules[specialCard.Power]; !ok {
+			return fmt.Errorf("Rule set %q has unknown power %q for %s", ruleSet.Name, specialCard.Power, specialCard.Rank)
+		}
+		if (specialCard.Rank < Ace || specialCard.Rank > King) && specialCard.Rank != Joker {
+			return fmt.Errorf("Rule set %q has a special card with unknown rank %d", ruleSet.Name, specialCard.Rank)
+		}
+	}
+
+	switch ruleSet.Jokers {
+	case JokerMode_None, JokerMode_High, JokerMode_Wild:
+	default:
+		return fmt.Errorf("Rule set %q has unknown joker mode %q", ruleSet.Name, ruleSet.Jokers)
+	}
+
+	switch ruleSet.StartingPlayer {
+	case StartingPlayer_LowestCard, StartingPlayer_First, StartingPlayer_Random:
+	default:
+		return fmt.Errorf("Rule set %q has unknown starting player rule %q", ruleSet.Name, ruleSet.StartingPlayer)
+	}
+	return nil
+}
+
+func (ruleSet RuleSet) clone() RuleSet {
+	ruleSet.SpecialCards = slices.Clone(ruleSet.SpecialCards)
+	return ruleSet
+}
+
+func (ruleSet RuleSet) rules() []Rule {
+	rules := make([]Rule, 0, len(ruleSet.SpecialCards)+2)
+	if ruleSet.Jokers == JokerMode_Wild {
+		rules = append(rules, MirrorRule(Joker))
+	}
+	for _, specialCard := range ruleSet.SpecialCards {
+		if specialCard.Power == Power_Lower {
+			// A lower card decides the comparison itself, so it needs to know where the Ace ranks
+			rules = append(rules, lowerRule(specialCard.Rank, ruleSet.ranking()))
+		} else if newRule, ok := powerRules[specialCard.Power]; ok {
+			rules = append(rules, newRule(specialCard.Rank))
+		}
+	}
+	if ruleSet.AceHigh {
+		rules = append(rules, AceHighRule())
+	}
+	return rules
+}
+
+const MinPlayers int = 2
+
+// The most decks a game picks on its own or allows in a rule set.
+const MaxDecks int = 4
+
+var ErrTooFewPlayers = errors.New("Too few players")
+var ErrTooManyPlayers = errors.New("Too many players")
+
+// Checks the number of players can play a game with the rule set.
+// The error describes the limits for the rule set's decks.
+func (ruleSet RuleSet) ValidatePlayers(numOfPlayers int) error {
+	if numOfPlayers < MinPlayers {
+		return fmt.Errorf("%w: a game needs at least %d players, got %d", ErrTooFewPlayers, MinPlayers, numOfPlayers)
+	}
+
+	maxPlayers := ruleSet.MaxPlayers()
+	if numOfPlayers > maxPlayers {
+		decks := fmt.Sprintf("%d decks", ruleSet.Decks)
+		if ruleSet.Decks == 0 {
+			decks = fmt.Sprintf("up to %d decks", MaxDecks)
+		}
+		return fmt.Errorf("%w: rule set %q deals %d cards to each player from %s of %d cards, which is enough for at most %d players, got %d",
+			ErrTooManyPlayers, ruleSet.Name, ruleSet.cardsPerPlayer(), decks, deckSize(ruleSet.Jokers != JokerMode_None), maxPlayers, numOfPlayers)
+	}
+	return nil
+}
+
+// Returns the most players that can be dealt a full hand, either from the rule set's decks
+// or from as many decks as the game would pick, up to MaxDecks.
+func (ruleSet RuleSet) MaxPlayers() int {
+	if ruleSet.cardsPerPlayer() == 0 {
+		return 0
+	}
+
+	size := deckSize(ruleSet.Jokers != JokerMode_None)
+	if ruleSet.Decks > 0 {
+		return ruleSet.Decks * size / ruleSet.cardsPerPlayer()
+	}
+
+	maxPlayers := 0
+	for ruleSet.numOfDecks(maxPlayers+1) <= MaxDecks {
+		maxPlayers++
+	}
+	return maxPlayers
+}
+
+// One deck is enough for this many players. Beyond that there are barely any cards left to draw.
+const playersPerDeck int = 5
+
+// Returns the number of decks to play with. Zero decks in the rule set picks one deck for every playersPerDeck players,
+// adding more if needed to deal every hand.
+func (ruleSet RuleSet) numOfDecks(numOfPlayers int) int {
+	if ruleSet.Decks > 0 {
+		return ruleSet.Decks
+	}
+
+	size := deckSize(ruleSet.Jokers != JokerMode_None)
+	dealt := numOfPlayers * ruleSet.cardsPerPlayer()
+	return max(1, (numOfPlayers+playersPerDeck-1)/playersPerDeck, (dealt+size-1)/size)
+}
+
+func (ruleSet RuleSet) cardsPerPlayer() int {
+	return ruleSet.FaceDownCards + ruleSet.FaceUpCards + ruleSet.InHandCards
+}
+
+// Returns how cards are ranked against each other outside of the special card rules.
+func (ruleSet RuleSet) ranking() CardCompare {
+	if ruleSet.AceHigh {
+		return AceHighCompare
+	}
+	return NumericCompare
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	for name, ruleSet := range Presets {
+		if name != ruleSet.Name {
+			t.Errorf("Preset name mismatch. Key: %s, name: %s.", name, ruleSet.Name)
+		}
+		if err := ruleSet.Validate(); err != nil {
+			t.Errorf("Preset %s is invalid: %s", name, err)
+		}
+	}
+}
+
+func TestLoadRuleSet(t *testing.T) {
+	ruleSet, err := LoadRuleSet(strings.NewReader(`{
+		"name": "house",
+		"faceDownCards": 4,
+		"minHandSize": 5,
+		"jokers": "none",
+		"specialCards": [{"rank": 2, "power": "reset"}, {"rank": 12, "power": "reverse"}],
+		"startingPlayer": "first"
+	}`))
+	if err != nil {
+		t.Fatalf("Expected rule set to load. Error: %s", err)
+	}
+
+	if ruleSet.Name != "house" || ruleSet.FaceDownCards != 4 || ruleSet.MinHandSize != 5 || ruleSet.Jokers != JokerMode_None {
+		t.Fatalf("Rule set fields mismatch. Actual: %+v.", ruleSet)
+	}
+	if ruleSet.FaceUpCards != StandardRules.FaceUpCards || ruleSet.InHandCards != StandardRules.InHandCards {
+		t.Fatalf("Missing fields should keep the standard values. Actual: %+v.", ruleSet)
+	}
+	expected := []SpecialCard{{Two, Power_Reset}, {Queen, Power_Reverse}}
+	if !slices.Equal(ruleSet.SpecialCards, expected) {
+		t.Fatalf("Special cards mismatch. Expected: %v, actual: %v.", expected, ruleSet.SpecialCards)
+	}
+	if len(StandardRules.SpecialCards) != 4 {
+		t.Fatalf("Loading should not change StandardRules. Actual: %v.", StandardRules.SpecialCards)
+	}
+
+	game := newGame(t, 3, ruleSet)
+	for _, hand := range game.Hands {
+		if len(hand.FaceDown) != 4 {
+			t.Fatalf("Expected 4 face down cards. Actual: %v.", hand.FaceDown)
+		}
+	}
+	game.Init()
+	if game.currentPlayerId != 0 {
+		t.Fatalf("First player should start. Actual: %d.", game.currentPlayerId)
+	}
+
+	collectedDeck := slices.Clone(game.DrawPile.Cards)
+	for _, hand := range game.Hands {
+		collectedDeck = append(collectedDeck, hand.InHand...)
+		collectedDeck = append(collectedDeck, hand.FaceUp...)
+		collectedDeck = append(collectedDeck, hand.FaceDown...)
+	}
+	if len(collectedDeck) != 52 || slices.ContainsFunc(collectedDeck, func(c Card) bool { return c.Rank == Joker }) {
+		t.Fatalf("Expected a deck of 52 cards without jokers. Actual: %v.", collectedDeck)
+	}
+}
+
+func TestLoadRuleSetSpecialCardOrder(t *testing.T) {
+	ruleSet, err := LoadRuleSet(strings.NewReader(`{
+		"specialCards": [{"rank": 7, "power": "lower"}, {"rank": 10, "power": "burn"}, {"rank": 2, "power": "reset"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Expected rule set to load. Error: %s", err)
+	}
+
+	for _, card := range []Card{clubs[9], clubs[1]} {
+		game := newTestGame(t, nil, []Card{card}, []Card{spades[5]})
+		game.setRuleSet(ruleSet)
+		game.InPlayPile.AddCard(spades[6])
+
+		if result := game.PlayHand(Play{PlayerId: 0, Cards: []Card{card}}); !result.Success {
+			t.Fatalf("Expected %v to be playable on a seven whatever order the rules are listed in. Status: %d.", card, result.Status)
+		}
+	}
+}
+
+func TestRuleSetNotShared(t *testing.T) {
+	game := newGame(t, 2, Presets[StandardRules.Name])
+
+	rules := game.Rules()
+	rules.SpecialCards[0] = SpecialCard{Ace, Power_Burn}
+	if game.Rules().SpecialCards[0] != StandardRules.SpecialCards[0] {
+		t.Fatalf("Changing the returned rules should not change the game. Actual: %v.", game.Rules().SpecialCards)
+	}
+	if Presets[StandardRules.Name].SpecialCards[0] != (SpecialCard{Two, Power_Reset}) {
+		t.Fatalf("Changing the returned rules should not change the preset. Actual: %v.", Presets[StandardRules.Name].SpecialCards)
+	}
+
+	ruleSet := StandardRules.clone()
+	game = newGame(t, 2, ruleSet)
+	ruleSet.SpecialCards[0] = SpecialCard{Ace, Power_Burn}
+	if game.Rules().SpecialCards[0] != (SpecialCard{Two, Power_Reset}) {
+		t.Fatalf("Changing the caller's rules should not change the game. Actual: %v.", game.Rules().SpecialCards)
+	}
+}
+
+func TestLoadRuleSetInvalid(t *testing.T) {
+	testCases := []string{
+		`{"specialCards": [{"rank": 2, "power": "explode"}]}`,
+		`{"specialCards": [{"rank": 14, "power": "burn"}]}`,
+		`{"faceDownCards": -1}`,
+		`{"startingPlayer": "youngest"}`,
+		`{"jokers": true}`,
+		`{"jokers": "tame"}`,
+		`{"unknownField": true}`,
+		`{"name": `,
+	}
+
+	for _, testCase := range testCases {
+		if _, err := LoadRuleSet(strings.NewReader(testCase)); err == nil {
+			t.Errorf("Expected rule set to be rejected: %s", testCase)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Bumped whenever the snapshot format changes in a way older code can't read.
+const SnapshotVersion int = 4
+
+// Snapshot is the complete state of a game, including its rules, in a form that can be encoded as JSON.
+type Snapshot struct {
+	Version         int     `json:"version"`
+	Seed            uint64  `json:"seed"`
+	Rules           RuleSet `json:"rules"`
+	DrawPile        []Card  `json:"drawPile"`
+	InPlayPile      []Card  `json:"inPlayPile"`
+	DiscardPile     []Card  `json:"discardPile"`
+	Hands           []Hand  `json:"hands"`
+	Round           int     `json:"round"`
+	CurrentPlayerId int     `json:"currentPlayerId"`
+	Direction       int     `json:"direction"`
+	Finished        []int   `json:"finished"`
+	Log             []Event `json:"log"`
+	// The states plays can be undone or redone back to, oldest first. They have no log or history of their own.
+	UndoHistory []Snapshot `json:"undoHistory,omitempty"`
+	RedoHistory []Snapshot `json:"redoHistory,omitempty"`
+}
+
+// Returns a copy of the game's state that shares nothing with the game.
+func (game *Game) Snapshot() Snapshot {
+	snapshot := game.state()
+	snapshot.Log = game.Events()
+	snapshot.UndoHistory = cloneStates(game.undoHistory)
+	snapshot.RedoHistory = cloneStates(game.redoHistory)
+	return snapshot
+}
+
+// Returns a copy of the game's state, leaving out the log.
+func (game *Game) state() Snapshot {
+	hands := make([]Hand, 0, len(game.Hands))
+	for _, hand := range game.Hands {
+		hands = append(hands, hand.clone())
+	}
+
+	return Snapshot{
+		Version:         SnapshotVersion,
+		Seed:            game.Seed,
+		Rules:           game.ruleSet.clone(),
+		DrawPile:        slices.Clone(game.DrawPile.Cards),
+		InPlayPile:      slices.Clone(game.InPlayPile.Cards),
+		DiscardPile:     slices.Clone(game.DiscardPile.Cards),
+		Hands:           hands,
+		Round:           game.round,
+		CurrentPlayerId: game.currentPlayerId,
+		Direction:       game.direction,
+		Finished:        slices.Clone(game.finished),
+	}
+}
+
+// Puts the game back into the state, leaving the log alone.
+func (game *Game) restoreState(state Snapshot) {
+	hands := make([]Hand, 0, len(state.Hands))
+	for _, hand := range state.Hands {
+		hands = append(hands, hand.clone())
+	}
+
+	game.Seed = state.Seed
+	game.DrawPile = &Deck{Cards: slices.Clone(state.DrawPile)}
+	game.InPlayPile = &Deck{Cards: slices.Clone(state.InPlayPile)}
+	game.DiscardPile = &Deck{Cards: slices.Clone(state.DiscardPile)}
+	game.Hands = hands
+	game.round = state.Round
+	game.currentPlayerId = state.CurrentPlayerId
+	game.direction = state.Direction
+	game.finished = slices.Clone(state.Finished)
+	game.setRuleSet(state.Rules)
+}
+
+func (game *Game) MarshalSnapshot() ([]byte, error) {
+	return json.Marshal(game.Snapshot())
+}
+
+// Creates a game in exactly the state the snapshot was taken in, including what can be undone and redone.
+func RestoreGame(snapshot Snapshot) (*Game, error) {
+	if err := snapshot.validate(); err != nil {
+		return nil, err
+	}
+	if history := len(snapshot.UndoHistory) + len(snapshot.RedoHistory); history > MaxUndoHistory {
+		return nil, fmt.Errorf("Snapshot history has %d states, but at most %d are kept", history, MaxUndoHistory)
+	}
+	for _, state := range slices.Concat(snapshot.UndoHistory, snapshot.RedoHistory) {
+		if err := state.validate(); err != nil {
+			return nil, fmt.Errorf("Snapshot history: %w", err)
+		}
+		if len(state.Hands) != len(snapshot.Hands) {
+			return nil, fmt.Errorf("Snapshot history has %d hands, but the game has %d", len(state.Hands), len(snapshot.Hands))
+		}
+	}
+
+	game := &Game{
+		log:         make([]Event, 0, len(snapshot.Log)),
+		undoHistory: cloneStates(snapshot.UndoHistory),
+		redoHistory: cloneStates(snapshot.RedoHistory),
+	}
+	game.restoreState(snapshot)
+	for _, event := range snapshot.Log {
+		game.log = append(game.log, event.clone())
+	}
+	return game, nil
+}
+
+// Checks the snapshot's state describes a game, leaving its log and history alone.
+func (snapshot Snapshot) validate() error {
+	if snapshot.Version != SnapshotVersion {
+		return fmt.Errorf("Unsupported snapshot version %d, expected %d", snapshot.Version, SnapshotVersion)
+	}
+	if err := snapshot.Rules.Validate(); err != nil {
+		return err
+	}
+	if len(snapshot.Hands) < MinPlayers {
+		return fmt.Errorf("Snapshot has %d hands, but a game needs at least %d", len(snapshot.Hands), MinPlayers)
+	}
+	for i, hand := range snapshot.Hands {
+		if hand.Id != i {
+			return fmt.Errorf("Snapshot has hand %d at position %d", hand.Id, i)
+		}
+	}
+	switch {
+	case snapshot.CurrentPlayerId == NotStartedPlayerId, snapshot.CurrentPlayerId == EndedPlayerId:
+	case snapshot.CurrentPlayerId < 0 || snapshot.CurrentPlayerId >= len(snapshot.Hands):
+		return fmt.Errorf("Snapshot has unknown current player %d", snapshot.CurrentPlayerId)
+	}
+	if snapshot.Direction != 1 && snapshot.Direction != -1 {
+		return fmt.Errorf("Snapshot has invalid direction %d", snapshot.Direction)
+	}
+	return snapshot.validateFinished()
+}
+
+// Checks the finished players are distinct seats that fit the stage of the game. Everyone has finished
+// once it is over, the last being the shithead, and at least two players are left while it is going.
+func (snapshot Snapshot) validateFinished() error {
+	for i, playerId := range snapshot.Finished {
+		if playerId < 0 || playerId >= len(snapshot.Hands) {
+			return fmt.Errorf("Snapshot has unknown finished player %d", playerId)
+		}
+		if slices.Contains(snapshot.Finished[:i], playerId) {
+			return fmt.Errorf("Snapshot has player %d finished twice", playerId)
+		}
+		if playerId == snapshot.CurrentPlayerId {
+			return fmt.Errorf("Snapshot has finished player %d as the current player", playerId)
+		}
+	}
+
+	switch snapshot.CurrentPlayerId {
+	case EndedPlayerId:
+		if len(snapshot.Finished) != len(snapshot.Hands) {
+			return fmt.Errorf("Snapshot of a game that is over has %d of %d players finished", len(snapshot.Finished), len(snapshot.Hands))
+		}
+	case NotStartedPlayerId:
+		if len(snapshot.Finished) != 0 {
+			return fmt.Errorf("Snapshot of a game that hasn't started has %d players finished", len(snapshot.Finished))
+		}
+	default:
+		if len(snapshot.Finished) > len(snapshot.Hands)-2 {
+			return fmt.Errorf("Snapshot of a game that is going has %d of %d players finished", len(snapshot.Finished), len(snapshot.Hands))
+		}
+	}
+	return nil
+}
+
+// Copies states that have no log or history of their own, sharing nothing.
+func cloneStates(states []Snapshot) []Snapshot {
+	if len(states) == 0 {
+		return nil
+	}
+
+	clones := make([]Snapshot, 0, len(states))
+	for _, state := range states {
+		hands := make([]Hand, 0, len(state.Hands))
+		for _, hand := range state.Hands {
+			hands = append(hands, hand.clone())
+		}
+
+		state.Rules = state.Rules.clone()
+		state.DrawPile = slices.Clone(state.DrawPile)
+		state.InPlayPile = slices.Clone(state.InPlayPile)
+		state.DiscardPile = slices.Clone(state.DiscardPile)
+		state.Hands = hands
+		state.Finished = slices.Clone(state.Finished)
+		clones = append(clones, state)
+	}
+	return clones
+}
+
+func UnmarshalSnapshot(data []byte) (*Game, error) {
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("Invalid snapshot: %w", err)
+	}
+	return RestoreGame(snapshot)
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	game := newSeededGame(t, 4, WildRules, 7)
+	game.SwapCards(0, game.Hands[0].InHand[0], game.Hands[0].FaceUp[0])
+	game.MarkReady(0)
+
+	// Before the game has started
+	testSnapshotRoundTrip(t, game)
+
+	for i := range game.Hands {
+		game.MarkReady(i)
+	}
+	playOut(game, 30)
+
+	restored := testSnapshotRoundTrip(t, game)
+
+	// A restored game carries on exactly like the original
+	playOut(game, 500)
+	playOut(restored, 500)
+	if game.String() != restored.String() {
+		t.Fatalf("Restored game should play out like the original.\n%s\n%s", game, restored)
+	}
+}
+
+func testSnapshotRoundTrip(t *testing.T, game *Game) *Game {
+	data, err := game.MarshalSnapshot()
+	if err != nil {
+		t.Fatalf("Expected snapshot to encode. Error: %s", err)
+	}
+
+	restored, err := UnmarshalSnapshot(data)
+	if err != nil {
+		t.Fatalf("Expected snapshot to decode. Error: %s", err)
+	}
+
+	restoredData, err := restored.MarshalSnapshot()
+	if err != nil {
+		t.Fatalf("Expected restored snapshot to encode. Error: %s", err)
+	}
+	if !bytes.Equal(data, restoredData) {
+		t.Fatalf("Snapshot should round trip exactly.\n%s\n%s", data, restoredData)
+	}
+	if game.String() != restored.String() {
+		t.Fatalf("Restored game mismatch.\n%s\n%s", game, restored)
+	}
+	return restored
+}
+
+func TestSnapshotUndoHistory(t *testing.T) {
+	game := newSeededGame(t, 2, PracticeRules, 7)
+	game.Init()
+	playOut(game, 3)
+	if status := game.Undo(); status != Success {
+		t.Fatalf("Expected undo to succeed. Status: %d.", status)
+	}
+
+	restored := testSnapshotRoundTrip(t, game)
+	for _, g := range []*Game{game, restored} {
+		if status := g.Undo(); status != Success {
+			t.Fatalf("Expected undo after restoring to succeed. Status: %d.", status)
+		}
+		if status := g.Redo(); status != Success {
+			t.Fatalf("Expected redo after restoring to succeed. Status: %d.", status)
+		}
+		if status := g.Redo(); status != Success {
+			t.Fatalf("Expected the redo saved in the snapshot to succeed. Status: %d.", status)
+		}
+	}
+	if game.String() != restored.String() {
+		t.Fatalf("Restored game should undo and redo like the original.\n%s\n%s", game, restored)
+	}
+
+	// The history belongs to the restored game alone
+	snapshot := game.Snapshot()
+	snapshot.UndoHistory[0].Hands[0].InHand[0] = ErrorCard
+	if game.undoHistory[0].Hands[0].InHand[0] == ErrorCard {
+		t.Fatal("Changing a snapshot's history should not change the game.")
+	}
+}
+
+func TestSnapshotShareNothing(t *testing.T) {
+	game := newSeededGame(t, 3, StandardRules, 7)
+	snapshot := game.Snapshot()
+
+	snapshot.Hands[0].InHand[0] = ErrorCard
+	snapshot.DrawPile[0] = ErrorCard
+	snapshot.Rules.SpecialCards[0].Power = Power_Reverse
+
+	if game.Hands[0].InHand[0] == ErrorCard || game.DrawPile.Cards[0] == ErrorCard {
+		t.Fatal("Changing a snapshot should not change the game.")
+	}
+	if game.Rules().SpecialCards[0].Power != Power_Reset || StandardRules.SpecialCards[0].Power != Power_Reset {
+		t.Fatal("Changing a snapshot should not change the game's rules.")
+	}
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	game := newSeededGame(t, 3, StandardRules, 7)
+	data, _ := game.MarshalSnapshot()
+
+	testCases := []string{
+		strings.Replace(string(data), fmt.Sprintf(`"version":%d`, SnapshotVersion), `"version":1`, 1),
+		strings.Replace(string(data), `"direction":1`, `"direction":0`, 1),
+		strings.Replace(string(data), `"currentPlayerId":-1`, `"currentPlayerId":3`, 1),
+		strings.Replace(string(data), `"power":"reset"`, `"power":"explode"`, 1),
+		string(data[:len(data)/2]),
+		// Over with nobody finished
+		strings.Replace(string(data), `"currentPlayerId":-1`, `"currentPlayerId":-2`, 1),
+		// Not started with someone finished
+		strings.Replace(string(data), `"finished":[]`, `"finished":[0]`, 1),
+	}
+	testCases = append(testCases, invalidFinished(t)...)
+
+	for _, testCase := range testCases {
+		if testCase == string(data) {
+			t.Fatalf("Test case did not change the snapshot: %s", testCase)
+		}
+		if _, err := UnmarshalSnapshot([]byte(testCase)); err == nil {
+			t.Errorf("Expected snapshot to be rejected: %s", testCase)
+		}
+	}
+}
+
+// Returns snapshots of a started game whose finished players don't fit it.
+func invalidFinished(t *testing.T) []string {
+	game := newSeededGame(t, 4, StandardRules, 7)
+	game.Init()
+	current := game.currentPlayerId
+	other := (current + 1) % 4
+
+	testCases := make([]string, 0)
+	for _, finished := range [][]int{{other, 7}, {-1}, {other, other}, {current}, {other, (other + 1) % 4, (other + 2) % 4}} {
+		snapshot := game.Snapshot()
+		snapshot.Finished = finished
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			t.Fatalf("Expected snapshot to encode. Error: %s", err)
+		}
+		testCases = append(testCases, string(data))
+	}
+	return testCases
+}
@@ -0,0 +1,55 @@
+package engine
+
+import "slices"
+
+// How many plays can be undone at most. Older plays drop out of the history, which keeps snapshots small.
+const MaxUndoHistory int = 100
+
+// Takes back the last play, putting the piles, hands, round, current player and direction back
+// exactly as they were before it. The log keeps the play and records the undo after it.
+func (game *Game) Undo() Status {
+	if !game.ruleSet.Undo {
+		return Undo_Disabled
+	}
+	if len(game.undoHistory) == 0 {
+		return Undo_NothingToUndo
+	}
+
+	last := len(game.undoHistory) - 1
+	game.redoHistory = append(game.redoHistory, game.state())
+	game.restoreState(game.undoHistory[last])
+	game.undoHistory = game.undoHistory[:last]
+
+	game.emit(Event{Type: Event_Undo, PlayerId: game.currentPlayerId})
+	return Success
+}
+
+// Makes the last play that was taken back again. Any new play clears what can be redone.
+func (game *Game) Redo() Status {
+	if !game.ruleSet.Undo {
+		return Undo_Disabled
+	}
+	if len(game.redoHistory) == 0 {
+		return Undo_NothingToRedo
+	}
+
+	last := len(game.redoHistory) - 1
+	game.undoHistory = append(game.undoHistory, game.state())
+	game.restoreState(game.redoHistory[last])
+	game.redoHistory = game.redoHistory[:last]
+
+	game.emit(Event{Type: Event_Redo, PlayerId: game.currentPlayerId})
+	return Success
+}
+
+// Remembers the state before a play so it can be undone.
+func (game *Game) saveUndo() {
+	if !game.ruleSet.Undo {
+		return
+	}
+	game.undoHistory = append(game.undoHistory, game.state())
+	if extra := len(game.undoHistory) - MaxUndoHistory; extra > 0 {
+		game.undoHistory = slices.Delete(game.undoHistory, 0, extra)
+	}
+	game.redoHistory = nil
+}
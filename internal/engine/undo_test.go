@@ -0,0 +1,91 @@
+package engine
+
+import "testing"
+
+func TestUndoRedo(t *testing.T) {
+	game := newSeededGame(t, 3, PracticeRules, 9)
+	game.Init()
+
+	states := []string{game.String()}
+	for i := 0; i < 5; i++ {
+		playOut(game, 1)
+		states = append(states, game.String())
+	}
+
+	for i := len(states) - 2; i >= 0; i-- {
+		if status := game.Undo(); status != Success {
+			t.Fatalf("Expected undo to succeed. Status: %d.", status)
+		}
+		if game.String() != states[i] {
+			t.Fatalf("Undo should restore the state before the play.\nExpected:\n%s\nActual:\n%s", states[i], game.String())
+		}
+	}
+	if status := game.Undo(); status != Undo_NothingToUndo {
+		t.Fatalf("Expected Undo_NothingToUndo. Status: %d.", status)
+	}
+
+	for i := 1; i < len(states); i++ {
+		if status := game.Redo(); status != Success {
+			t.Fatalf("Expected redo to succeed. Status: %d.", status)
+		}
+		if game.String() != states[i] {
+			t.Fatalf("Redo should restore the state after the play.\nExpected:\n%s\nActual:\n%s", states[i], game.String())
+		}
+	}
+	if status := game.Redo(); status != Undo_NothingToRedo {
+		t.Fatalf("Expected Undo_NothingToRedo. Status: %d.", status)
+	}
+
+	game.Undo()
+	playOut(game, 1)
+	if status := game.Redo(); status != Undo_NothingToRedo {
+		t.Fatalf("A new play should clear what can be redone. Status: %d.", status)
+	}
+
+	replayed, err := Replay(game.Events())
+	if err != nil {
+		t.Fatalf("Expected a log with undo and redo to replay. Error: %s", err)
+	}
+	if replayed.String() != game.String() {
+		t.Fatalf("Replayed game mismatch.\n%s\n%s", game, replayed)
+	}
+}
+
+func TestUndoDisabled(t *testing.T) {
+	game := newSeededGame(t, 3, StandardRules, 9)
+	game.Init()
+	playOut(game, 2)
+
+	if status := game.Undo(); status != Undo_Disabled {
+		t.Fatalf("Expected Undo_Disabled. Status: %d.", status)
+	}
+	if status := game.Redo(); status != Undo_Disabled {
+		t.Fatalf("Expected Undo_Disabled. Status: %d.", status)
+	}
+}
+
+func TestUndoHistoryLimit(t *testing.T) {
+	game := newSeededGame(t, 2, PracticeRules, 9)
+	game.Init()
+
+	for i := 0; i < MaxUndoHistory+10 && !game.IsOver(); i++ {
+		playOut(game, 1)
+	}
+	if game.IsOver() || len(game.undoHistory) != MaxUndoHistory {
+		t.Fatalf("Expected only the last %d plays to be kept. Actual: %d.", MaxUndoHistory, len(game.undoHistory))
+	}
+
+	undone := 0
+	for game.Undo() == Success {
+		undone++
+	}
+	if undone != MaxUndoHistory || len(game.redoHistory) != MaxUndoHistory {
+		t.Fatalf("Expected the kept plays to be undone. Undone: %d.", undone)
+	}
+
+	snapshot := game.Snapshot()
+	snapshot.UndoHistory = make([]Snapshot, MaxUndoHistory+1)
+	if _, err := RestoreGame(snapshot); err == nil {
+		t.Fatal("Expected a snapshot with too much history to be rejected.")
+	}
+}
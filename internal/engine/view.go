@@ -0,0 +1,108 @@
+package engine
+
+import "slices"
+
+// Viewing a game as a spectator, who can't see any private cards.
+const SpectatorId int = -4
+
+// HandView is what a viewer can see of a hand. InHand is only set for the viewer's own hand.
+type HandView struct {
+	Id            int    `json:"id"`
+	InHand        []Card `json:"inHand,omitempty"`
+	InHandCount   int    `json:"inHandCount"`
+	FaceUp        []Card `json:"faceUp"`
+	FaceDownCount int    `json:"faceDownCount"`
+	Ready         bool   `json:"ready"`
+	Finished      bool   `json:"finished"`
+}
+
+// GameView is the state of a game as one player or a spectator is allowed to see it.
+// Face down cards and the order of the draw pile are hidden from everyone.
+type GameView struct {
+	ViewerId         int        `json:"viewerId"`
+	Rules            RuleSet    `json:"rules"`
+	Hands            []HandView `json:"hands"`
+	DrawPileCount    int        `json:"drawPileCount"`
+	InPlayPile       []Card     `json:"inPlayPile"`
+	DiscardPileCount int        `json:"discardPileCount"`
+	Round            int        `json:"round"`
+	CurrentPlayerId  int        `json:"currentPlayerId"`
+	Direction        int        `json:"direction"`
+	GameOver         bool       `json:"gameOver"`
+	Standings        []int      `json:"standings"`
+	// How many events the game had when the view was taken. EventsFor the viewer from Seq on is what happens next.
+	Seq int `json:"seq"`
+}
+
+// Returns the game as the player sees it. Anyone who isn't a player in the game gets the spectator view.
+func (game *Game) ViewFor(playerId int) GameView {
+	if playerId < 0 || playerId >= len(game.Hands) {
+		playerId = SpectatorId
+	}
+
+	hands := make([]HandView, 0, len(game.Hands))
+	for _, hand := range game.Hands {
+		handView := HandView{
+			Id:            hand.Id,
+			InHandCount:   len(hand.InHand),
+			FaceUp:        slices.Clone(hand.FaceUp),
+			FaceDownCount: len(hand.FaceDown),
+			Ready:         hand.Ready,
+			Finished:      game.isFinished(hand.Id),
+		}
+		if hand.Id == playerId {
+			handView.InHand = slices.Clone(hand.InHand)
+		}
+		hands = append(hands, handView)
+	}
+
+	return GameView{
+		ViewerId:         playerId,
+		Rules:            game.ruleSet.clone(),
+		Hands:            hands,
+		DrawPileCount:    len(game.DrawPile.Cards),
+		InPlayPile:       slices.Clone(game.InPlayPile.Cards),
+		DiscardPileCount: len(game.DiscardPile.Cards),
+		Round:            game.round,
+		CurrentPlayerId:  game.currentPlayerId,
+		Direction:        game.direction,
+		GameOver:         game.IsOver(),
+		Standings:        game.Standings(),
+		Seq:              len(game.log),
+	}
+}
+
+// Returns the game as a spectator sees it, without anyone's cards in hand.
+func (game *Game) SpectatorView() GameView {
+	return game.ViewFor(SpectatorId)
+}
+
+// Returns the events from seq onwards as the player is allowed to see them. Cards the player can't see are
+// replaced by ErrorCard, so the number of cards still shows. That is every face down card, other players'
+// cards in hand, and the cards they draw. The seed is left out too, since the whole deal follows from it.
+// Anyone who isn't a player in the game gets the events as a spectator sees them.
+func (game *Game) EventsFor(playerId int, seq int) []Event {
+	events := game.EventsSince(seq)
+	for i := range events {
+		event := &events[i]
+		switch event.Type {
+		case Event_Create:
+			event.Seed = 0
+		case Event_Deal:
+			if event.Zone == Zone_FaceDown || (event.Zone == Zone_InHand && event.PlayerId != playerId) {
+				hideCards(event.Cards)
+			}
+		case Event_Draw:
+			if event.PlayerId != playerId {
+				hideCards(event.Cards)
+			}
+		}
+	}
+	return events
+}
+
+func hideCards(cards []Card) {
+	for i := range cards {
+		cards[i] = ErrorCard
+	}
+}
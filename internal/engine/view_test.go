@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestViewFor(t *testing.T) {
+	game := newSeededGame(t, 3, StandardRules, 13)
+	game.Init()
+	playOut(game, 4)
+
+	view := game.ViewFor(1)
+	if view.ViewerId != 1 || view.CurrentPlayerId != game.currentPlayerId || view.Round != game.round {
+		t.Fatalf("View mismatch. Actual: %+v.", view)
+	}
+	if view.DrawPileCount != len(game.DrawPile.Cards) || !slices.Equal(view.InPlayPile, game.InPlayPile.Cards) {
+		t.Fatalf("Piles mismatch. Actual: %+v.", view)
+	}
+
+	for _, handView := range view.Hands {
+		hand := game.Hands[handView.Id]
+		if handView.Id == 1 {
+			if !slices.Equal(handView.InHand, hand.InHand) {
+				t.Fatalf("Player should see their own hand. Expected: %v, actual: %v.", hand.InHand, handView.InHand)
+			}
+		} else if handView.InHand != nil {
+			t.Fatalf("Player should not see hand %d. Actual: %v.", handView.Id, handView.InHand)
+		}
+		if handView.InHandCount != len(hand.InHand) || handView.FaceDownCount != len(hand.FaceDown) {
+			t.Fatalf("Card counts mismatch for hand %d. Actual: %+v.", handView.Id, handView)
+		}
+		if !slices.Equal(handView.FaceUp, hand.FaceUp) {
+			t.Fatalf("Face up cards should be visible for hand %d. Expected: %v, actual: %v.", handView.Id, hand.FaceUp, handView.FaceUp)
+		}
+	}
+
+	view.Hands[1].InHand[0] = ErrorCard
+	if game.Hands[1].InHand[0] == ErrorCard {
+		t.Fatal("Changing a view should not change the game.")
+	}
+}
+
+func TestSpectatorView(t *testing.T) {
+	game := newSeededGame(t, 3, StandardRules, 13)
+	game.Init()
+
+	for _, view := range []GameView{game.SpectatorView(), game.ViewFor(3), game.ViewFor(-1)} {
+		if view.ViewerId != SpectatorId {
+			t.Fatalf("Expected a spectator view. Actual: %d.", view.ViewerId)
+		}
+		for _, handView := range view.Hands {
+			if handView.InHand != nil {
+				t.Fatalf("Spectators should not see hand %d. Actual: %v.", handView.Id, handView.InHand)
+			}
+		}
+	}
+}
+
+func TestEventsFor(t *testing.T) {
+	game := newSeededGame(t, 3, StandardRules, 13)
+	game.Init()
+	playOut(game, 20)
+
+	events := game.EventsFor(1, 0)
+	if len(events) != len(game.log) {
+		t.Fatalf("Expected every event. Expected: %d, actual: %d.", len(game.log), len(events))
+	}
+	if events[0].Seed != 0 {
+		t.Fatalf("Seed should be hidden. Actual: %d.", events[0].Seed)
+	}
+
+	draws := 0
+	for i, event := range events {
+		original := game.log[i]
+		if len(event.Cards) != len(original.Cards) {
+			t.Fatalf("Event %d should keep its number of cards. Expected: %v, actual: %v.", i, original.Cards, event.Cards)
+		}
+
+		hidden := event.Type == Event_Deal && event.Zone == Zone_FaceDown ||
+			event.Type == Event_Deal && event.Zone == Zone_InHand && event.PlayerId != 1 ||
+			event.Type == Event_Draw && event.PlayerId != 1
+		for _, card := range event.Cards {
+			if hidden && card != ErrorCard {
+				t.Fatalf("Event %d should hide its cards. Actual: %+v.", i, event)
+			}
+		}
+		if !hidden && !slices.Equal(event.Cards, original.Cards) {
+			t.Fatalf("Event %d should show its cards. Expected: %v, actual: %v.", i, original.Cards, event.Cards)
+		}
+		if event.Type == Event_Draw {
+			draws++
+		}
+	}
+	if draws == 0 {
+		t.Fatal("Expected the game to have draws to check.")
+	}
+	if game.log[0].Seed != 13 || slices.Contains(game.log[1].Cards, ErrorCard) {
+		t.Fatal("Hiding events should not change the game's log.")
+	}
+
+	// Catching up from a view only gives what happened after it
+	view := game.ViewFor(1)
+	playOut(game, 1)
+	if missed := game.EventsFor(1, view.Seq); len(missed) == 0 || missed[0].Seq != view.Seq {
+		t.Fatalf("Expected the events since the view. Actual: %+v.", missed)
+	}
+}
@@ -0,0 +1,283 @@
+package lobby
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ishunyu/shithead/internal/engine"
+	"github.com/ishunyu/shithead/internal/protocol"
+)
+
+var (
+	ErrNoSuchRoom  = errors.New("No such room")
+	ErrRoomFull    = errors.New("Room is full")
+	ErrInRoom      = errors.New("Already in a room")
+	ErrNotInRoom   = errors.New("Not in a room")
+	ErrNotHost     = errors.New("Only the host can do that")
+	ErrGameStarted = errors.New("Game has already started")
+	ErrNotStarted  = errors.New("Game has not started")
+	ErrInvalidRoom = errors.New("Invalid room")
+)
+
+// A member is anyone who can sit in a room, usually a WebSocket connection.
+// Send may be called from any goroutine, and is called while the lobby and room are locked, so it must not block.
+type Member interface {
+	Send(envelope protocol.Envelope)
+}
+
+// Lobby keeps track of every room and which room each member is in.
+// Lock order is the lobby before any of its rooms.
+type Lobby struct {
+	mu      sync.Mutex
+	rooms   map[string]*Room
+	members map[Member]*Room
+	held    map[string]*heldSeat
+	nextId  int
+}
+
+// A seat kept for a member who lost their connection.
+type heldSeat struct {
+	member Member
+	timer  *time.Timer
+	// Set once the seat's game has been abandoned, so the member can be told when they come back.
+	abandoned *protocol.Envelope
+}
+
+func New() *Lobby {
+	return &Lobby{
+		rooms:   make(map[string]*Room),
+		members: make(map[Member]*Room),
+		held:    make(map[string]*heldSeat),
+	}
+}
+
+// Opens a room with the preset rule set and seats the host in it.
+func (lobby *Lobby) Create(request Request, name string, rules string, seats int) (*Room, error) {
+	if rules == "" {
+		rules = engine.StandardRules.Name
+	}
+	ruleSet, ok := engine.Presets[rules]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown rule set %q", ErrInvalidRoom, rules)
+	}
+	if err := ruleSet.ValidatePlayers(seats); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRoom, err)
+	}
+
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+
+	if _, ok := lobby.members[request.Member]; ok {
+		return nil, ErrInRoom
+	}
+
+	lobby.nextId++
+	room := &Room{
+		Id:      strconv.Itoa(lobby.nextId),
+		ruleSet: ruleSet,
+		seats:   seats,
+	}
+	room.join(request, name)
+	lobby.rooms[room.Id] = room
+	lobby.members[request.Member] = room
+	return room, nil
+}
+
+// Returns the rooms that haven't started and still have a free seat, oldest first.
+func (lobby *Lobby) Open() []protocol.RoomInfo {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+
+	rooms := make([]protocol.RoomInfo, 0, len(lobby.rooms))
+	for _, room := range lobby.rooms {
+		room.mu.Lock()
+		if room.game == nil && len(room.members) < room.seats {
+			rooms = append(rooms, room.info())
+		}
+		room.mu.Unlock()
+	}
+	// Room ids are counted up from 1, so shorter ids are older
+	slices.SortFunc(rooms, func(a, b protocol.RoomInfo) int {
+		return cmp.Or(cmp.Compare(len(a.Id), len(b.Id)), strings.Compare(a.Id, b.Id))
+	})
+	return rooms
+}
+
+// Seats the member in the room. Rooms can only be joined before their game starts.
+func (lobby *Lobby) Join(request Request, roomId string, name string) (*Room, error) {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+
+	if _, ok := lobby.members[request.Member]; ok {
+		return nil, ErrInRoom
+	}
+	room, ok := lobby.rooms[roomId]
+	if !ok {
+		return nil, ErrNoSuchRoom
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.game != nil {
+		return nil, ErrGameStarted
+	}
+	if len(room.members) >= room.seats {
+		return nil, ErrRoomFull
+	}
+	room.join(request, name)
+	lobby.members[request.Member] = room
+	return room, nil
+}
+
+// Returns the room the member is in, or nil.
+func (lobby *Lobby) RoomOf(member Member) *Room {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+	return lobby.members[member]
+}
+
+// Takes the member out of their room. The room is closed once it is empty.
+// Leaving a game that is still going ends it for everyone else.
+func (lobby *Lobby) Leave(member Member) error {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+
+	room, ok := lobby.members[member]
+	if !ok {
+		return ErrNotInRoom
+	}
+	delete(lobby.members, member)
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.game != nil && !room.game.IsOver() {
+		// The game can't go on with an empty seat, so everyone is sent back to the lobby
+		for _, other := range room.members {
+			delete(lobby.members, other.member)
+		}
+		away := make([]*heldSeat, 0)
+		for _, held := range lobby.held {
+			if held.member != member && room.seatOf(held.member) >= 0 {
+				away = append(away, held)
+			}
+		}
+		gameOver := room.abandon(member)
+		// Away members aren't sent the game over, so it waits for them to come back
+		if envelope, err := protocol.NewEnvelope(protocol.Type_GameOver, "", gameOver); err == nil {
+			for _, held := range away {
+				held.abandoned = &envelope
+			}
+		}
+		delete(lobby.rooms, room.Id)
+		return nil
+	}
+
+	room.leave(member)
+	if room.occupied() == 0 {
+		delete(lobby.rooms, room.Id)
+	}
+	return nil
+}
+
+// Keeps the member's seat under the key for the grace period, while they are away. If they haven't resumed
+// it by then, they leave. Members who aren't in a room have nothing to hold.
+func (lobby *Lobby) Hold(key string, member Member, grace time.Duration) {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+
+	room, ok := lobby.members[member]
+	if !ok {
+		return
+	}
+	room.mu.Lock()
+	room.setAway(member)
+	room.mu.Unlock()
+
+	if held, ok := lobby.held[key]; ok {
+		held.timer.Stop()
+	}
+	held := &heldSeat{member: member}
+	held.timer = time.AfterFunc(grace, func() {
+		lobby.release(key, held)
+	})
+	lobby.held[key] = held
+}
+
+// Gives the seat held under the key to the requesting member, and catches them up on what they missed.
+// Returns false if no seat is held under the key. If the seat's game was abandoned while they were away,
+// they are sent the game over instead and false is returned.
+func (lobby *Lobby) Resume(key string, request Request) bool {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+
+	held, ok := lobby.held[key]
+	if !ok {
+		return false
+	}
+	held.timer.Stop()
+	delete(lobby.held, key)
+	if held.abandoned != nil {
+		envelope := *held.abandoned
+		envelope.RequestId = request.Id
+		request.Member.Send(envelope)
+		return false
+	}
+
+	// The room may have closed while they were away
+	return lobby.move(held.member, request)
+}
+
+// Gives the member's seat to the requesting member straight away, and catches them up like Resume.
+// Returns false if the member isn't in a room.
+func (lobby *Lobby) Replace(member Member, request Request) bool {
+	lobby.mu.Lock()
+	defer lobby.mu.Unlock()
+	return lobby.move(member, request)
+}
+
+func (lobby *Lobby) move(member Member, request Request) bool {
+	room, ok := lobby.members[member]
+	if !ok {
+		return false
+	}
+	delete(lobby.members, member)
+	lobby.members[request.Member] = room
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.resume(member, request)
+	return true
+}
+
+// Gives up the seat held under the key straight away.
+func (lobby *Lobby) Release(key string) {
+	lobby.mu.Lock()
+	held, ok := lobby.held[key]
+	lobby.mu.Unlock()
+
+	if ok {
+		held.timer.Stop()
+		lobby.release(key, held)
+	}
+}
+
+func (lobby *Lobby) release(key string, held *heldSeat) {
+	lobby.mu.Lock()
+	if lobby.held[key] != held {
+		// Resumed or held again since
+		lobby.mu.Unlock()
+		return
+	}
+	delete(lobby.held, key)
+	lobby.mu.Unlock()
+
+	lobby.Leave(held.member)
+}
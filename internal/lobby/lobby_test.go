@@ -0,0 +1,378 @@
+package lobby
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ishunyu/shithead/internal/engine"
+	"github.com/ishunyu/shithead/internal/protocol"
+)
+
+// A member that keeps every message it is sent.
+type testMember struct {
+	received []protocol.Envelope
+}
+
+func (member *testMember) Send(envelope protocol.Envelope) {
+	member.received = append(member.received, envelope)
+}
+
+func (member *testMember) last(t *testing.T) protocol.Envelope {
+	t.Helper()
+	if len(member.received) == 0 {
+		t.Fatal("Expected the member to have been sent a message.")
+	}
+	return member.received[len(member.received)-1]
+}
+
+func (member *testMember) lastState(t *testing.T) engine.GameView {
+	t.Helper()
+	for i := len(member.received) - 1; i >= 0; i-- {
+		if member.received[i].Type == protocol.Type_State {
+			var payload protocol.StatePayload
+			if err := member.received[i].DecodePayload(&payload); err != nil {
+				t.Fatalf("Expected the state to decode. Error: %s.", err)
+			}
+			return payload.Game
+		}
+	}
+	t.Fatal("Expected the member to have been sent the state.")
+	return engine.GameView{}
+}
+
+func TestCreateAndJoin(t *testing.T) {
+	lobby := New()
+	host, guest, late := &testMember{}, &testMember{}, &testMember{}
+
+	room, err := lobby.Create(Request{Member: host, Id: "1"}, "host", engine.PagatRules.Name, 2)
+	if err != nil {
+		t.Fatalf("Expected the room to be created. Error: %s.", err)
+	}
+	if reply := host.last(t); reply.Type != protocol.Type_Room || reply.RequestId != "1" {
+		t.Fatalf("Expected the host to be sent the room in reply. Actual: %+v.", reply)
+	}
+
+	open := lobby.Open()
+	if len(open) != 1 || open[0].Id != room.Id || open[0].Rules != engine.PagatRules.Name {
+		t.Fatalf("Expected the room to be open. Actual: %+v.", open)
+	}
+
+	if _, err := lobby.Join(Request{Member: guest, Id: "2"}, room.Id, "guest"); err != nil {
+		t.Fatalf("Expected the guest to join. Error: %s.", err)
+	}
+	var payload protocol.RoomPayload
+	if err := host.last(t).DecodePayload(&payload); err != nil {
+		t.Fatalf("Expected the room to decode. Error: %s.", err)
+	}
+	if len(payload.Room.Players) != 2 || payload.Room.Players[1] != "guest" || payload.Seat != 0 {
+		t.Fatalf("Expected the host to be told the guest joined. Actual: %+v.", payload)
+	}
+	if host.last(t).RequestId != "" {
+		t.Fatalf("Only the guest's reply should carry their request id. Actual: %+v.", host.last(t))
+	}
+
+	if len(lobby.Open()) != 0 {
+		t.Fatalf("A full room should not be open. Actual: %+v.", lobby.Open())
+	}
+	if _, err := lobby.Join(Request{Member: late}, room.Id, "late"); !errors.Is(err, ErrRoomFull) {
+		t.Fatalf("Expected ErrRoomFull. Actual: %v.", err)
+	}
+	if _, err := lobby.Join(Request{Member: late}, "42", "late"); !errors.Is(err, ErrNoSuchRoom) {
+		t.Fatalf("Expected ErrNoSuchRoom. Actual: %v.", err)
+	}
+	if _, err := lobby.Create(Request{Member: guest}, "guest", "", 2); !errors.Is(err, ErrInRoom) {
+		t.Fatalf("Expected ErrInRoom. Actual: %v.", err)
+	}
+}
+
+func TestCreateInvalid(t *testing.T) {
+	lobby := New()
+	member := &testMember{}
+
+	if _, err := lobby.Create(Request{Member: member}, "host", "poker", 4); !errors.Is(err, ErrInvalidRoom) {
+		t.Fatalf("Expected an unknown rule set to be ErrInvalidRoom. Actual: %v.", err)
+	}
+	if _, err := lobby.Create(Request{Member: member}, "host", "", 1); !errors.Is(err, ErrInvalidRoom) {
+		t.Fatalf("Expected a single seat to be ErrInvalidRoom. Actual: %v.", err)
+	}
+	if lobby.RoomOf(member) != nil {
+		t.Fatal("A failed create should not seat the member.")
+	}
+}
+
+func TestLeave(t *testing.T) {
+	lobby := New()
+	host, guest := &testMember{}, &testMember{}
+
+	room, _ := lobby.Create(Request{Member: host}, "host", "", 3)
+	lobby.Join(Request{Member: guest}, room.Id, "guest")
+
+	if err := lobby.Leave(host); err != nil {
+		t.Fatalf("Expected the host to leave. Error: %s.", err)
+	}
+	if err := room.Start(Request{Member: guest}); err == nil {
+		t.Fatal("Expected start with too few players to fail.")
+	}
+	if info := lobby.Open(); len(info) != 1 || info[0].Players[0] != "guest" {
+		t.Fatalf("Expected the guest to be the host now. Actual: %+v.", info)
+	}
+
+	lobby.Leave(guest)
+	if len(lobby.Open()) != 0 {
+		t.Fatalf("An empty room should be closed. Actual: %+v.", lobby.Open())
+	}
+	if err := lobby.Leave(guest); !errors.Is(err, ErrNotInRoom) {
+		t.Fatalf("Expected ErrNotInRoom. Actual: %v.", err)
+	}
+}
+
+func TestStartAndPlay(t *testing.T) {
+	lobby := New()
+	host, guest := &testMember{}, &testMember{}
+
+	room, _ := lobby.Create(Request{Member: host}, "host", engine.PlainRules.Name, 2)
+	if err := room.Play(Request{Member: host}, protocol.PlayPayload{}); !errors.Is(err, ErrNotStarted) {
+		t.Fatalf("Expected ErrNotStarted. Actual: %v.", err)
+	}
+	lobby.Join(Request{Member: guest}, room.Id, "guest")
+
+	if err := room.Start(Request{Member: guest}); !errors.Is(err, ErrNotHost) {
+		t.Fatalf("Expected ErrNotHost. Actual: %v.", err)
+	}
+	if err := room.Start(Request{Member: host, Id: "3"}); err != nil {
+		t.Fatalf("Expected the game to start. Error: %s.", err)
+	}
+	if _, err := lobby.Join(Request{Member: &testMember{}}, room.Id, "late"); !errors.Is(err, ErrGameStarted) {
+		t.Fatalf("Expected ErrGameStarted. Actual: %v.", err)
+	}
+
+	if view := guest.lastState(t); view.ViewerId != 1 || len(view.Hands[1].InHand) == 0 || len(view.Hands[0].InHand) != 0 {
+		t.Fatalf("Expected the guest to see only their own hand. Actual: %+v.", view)
+	}
+
+	// The starting player is whoever holds the lowest card
+	room.Ready(Request{Member: host})
+	room.Ready(Request{Member: guest})
+	view := host.lastState(t)
+	players := []*testMember{host, guest}
+	current := players[view.CurrentPlayerId]
+	other := players[1-view.CurrentPlayerId]
+
+	if err := room.PickUp(Request{Member: other}); err == nil {
+		t.Fatal("Expected a play out of turn to be rejected.")
+	} else {
+		var rejected *RejectedError
+		if !errors.As(err, &rejected) || rejected.Status != engine.Play_WrongPlayer {
+			t.Fatalf("Expected the play to be rejected with Play_WrongPlayer. Actual: %v.", err)
+		}
+	}
+
+	cards := current.lastState(t).Hands[view.CurrentPlayerId].InHand[:1]
+	if err := room.Play(Request{Member: current, Id: "4"}, protocol.PlayPayload{Cards: cards}); err != nil {
+		t.Fatalf("Expected the play to succeed. Error: %s.", err)
+	}
+	if reply := current.last(t); reply.Type != protocol.Type_State || reply.RequestId != "4" {
+		t.Fatalf("Expected the player to be sent the state in reply. Actual: %+v.", reply)
+	}
+	if other.lastState(t).Round != 1 {
+		t.Fatalf("Expected the other player to be sent the new state. Actual: %+v.", other.lastState(t))
+	}
+}
+
+func TestLeaveStartedGame(t *testing.T) {
+	lobby := New()
+	host, guest := &testMember{}, &testMember{}
+
+	room, _ := lobby.Create(Request{Member: host}, "host", "", 2)
+	lobby.Join(Request{Member: guest}, room.Id, "guest")
+	room.Start(Request{Member: host})
+
+	lobby.Leave(host)
+	var payload protocol.GameOverPayload
+	if reply := guest.last(t); reply.Type != protocol.Type_GameOver || reply.DecodePayload(&payload) != nil || !payload.Abandoned {
+		t.Fatalf("Expected the guest to be told the game was abandoned. Actual: %+v.", reply)
+	}
+	if lobby.RoomOf(guest) != nil {
+		t.Fatal("Expected the guest to be back in the lobby.")
+	}
+	if _, err := lobby.Create(Request{Member: guest}, "guest", "", 2); err != nil {
+		t.Fatalf("Expected the guest to be able to open a new room. Error: %s.", err)
+	}
+}
+
+func TestLeaveFinishedGame(t *testing.T) {
+	lobby := New()
+	host, guest, third := &testMember{}, &testMember{}, &testMember{}
+
+	room, _ := lobby.Create(Request{Member: host}, "host", "", 3)
+	lobby.Join(Request{Member: guest}, room.Id, "guest")
+	lobby.Join(Request{Member: third}, room.Id, "third")
+	room.Start(Request{Member: host})
+
+	// End the game as if the guest and third had played out their cards, leaving the host as the shithead
+	snapshot := room.game.Snapshot()
+	for _, playerId := range []int{1, 2} {
+		snapshot.Hands[playerId] = engine.Hand{Id: playerId, Ready: true}
+	}
+	snapshot.CurrentPlayerId = engine.EndedPlayerId
+	snapshot.Finished = []int{2, 1, 0}
+	game, err := engine.RestoreGame(snapshot)
+	if err != nil {
+		t.Fatalf("Expected the game to be restored. Error: %s.", err)
+	}
+	room.game = game
+
+	if err := lobby.Leave(host); err != nil {
+		t.Fatalf("Expected the host to leave. Error: %s.", err)
+	}
+	if lobby.RoomOf(guest) != room || room.seatOf(guest) != 1 || room.seatOf(third) != 2 {
+		t.Fatal("Expected the other players to keep their seats once the game is over.")
+	}
+
+	lobby.Hold("guest", guest, time.Hour)
+	reconnected := &testMember{}
+	lobby.Resume("guest", Request{Member: reconnected})
+	if view := reconnected.lastState(t); view.ViewerId != 1 {
+		t.Fatalf("Expected the guest to be shown their own hand. Actual viewer: %d.", view.ViewerId)
+	}
+
+	lobby.Leave(reconnected)
+	lobby.Leave(third)
+	if lobby.RoomOf(third) != nil || len(lobby.rooms) != 0 {
+		t.Fatal("Expected the room to close once everyone has left.")
+	}
+}
+
+func TestHoldAndResume(t *testing.T) {
+	lobby := New()
+	host, guest := &testMember{}, &testMember{}
+
+	room, _ := lobby.Create(Request{Member: host}, "host", engine.PlainRules.Name, 2)
+	lobby.Join(Request{Member: guest}, room.Id, "guest")
+	room.Start(Request{Member: host})
+	seen := guest.lastState(t).Seq
+
+	lobby.Hold("guest", guest, time.Hour)
+	received := len(guest.received)
+	room.Ready(Request{Member: host})
+	if len(guest.received) != received {
+		t.Fatalf("Nothing should be sent to an away member. Actual: %+v.", guest.received[received:])
+	}
+	if err := room.Ready(Request{Member: guest}); err != nil {
+		t.Fatalf("The held seat should still be in the game. Error: %s.", err)
+	}
+
+	reconnected := &testMember{}
+	if !lobby.Resume("guest", Request{Member: reconnected, Id: "9"}) {
+		t.Fatal("Expected the held seat to be resumed.")
+	}
+	if lobby.RoomOf(reconnected) != room || lobby.RoomOf(guest) != nil {
+		t.Fatal("Expected the seat to move to the reconnected member.")
+	}
+
+	types := make([]protocol.MessageType, 0)
+	for _, envelope := range reconnected.received {
+		types = append(types, envelope.Type)
+		if envelope.RequestId != "9" {
+			t.Fatalf("Expected the catch up to be in reply to the request. Actual: %+v.", envelope)
+		}
+	}
+	if !slices.Equal(types, []protocol.MessageType{protocol.Type_Room, protocol.Type_Events, protocol.Type_State}) {
+		t.Fatalf("Expected the room, missed events and state. Actual: %v.", types)
+	}
+	var missed protocol.EventsPayload
+	reconnected.received[1].DecodePayload(&missed)
+	view := reconnected.lastState(t)
+	if len(missed.Events) == 0 || missed.Events[0].Seq != seen || view.Seq != seen+len(missed.Events) {
+		t.Fatalf("Expected the events since the last state. Seen: %d, actual: %+v, view seq: %d.", seen, missed.Events, view.Seq)
+	}
+	if view.ViewerId != 1 || view.CurrentPlayerId == engine.NotStartedPlayerId {
+		t.Fatalf("Expected the current state of the started game. Actual: %+v.", view)
+	}
+
+	if lobby.Resume("guest", Request{Member: &testMember{}}) {
+		t.Fatal("A seat can only be resumed once.")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	lobby := New()
+	host, guest := &testMember{}, &testMember{}
+
+	room, _ := lobby.Create(Request{Member: host}, "host", engine.PlainRules.Name, 2)
+	lobby.Join(Request{Member: guest}, room.Id, "guest")
+	room.Start(Request{Member: host})
+
+	reconnected := &testMember{}
+	if !lobby.Replace(guest, Request{Member: reconnected, Id: "5"}) {
+		t.Fatal("Expected the seat to be replaced.")
+	}
+	if lobby.RoomOf(reconnected) != room || lobby.RoomOf(guest) != nil {
+		t.Fatal("Expected the seat to move to the new member.")
+	}
+	if view := reconnected.lastState(t); view.ViewerId != 1 || reconnected.last(t).RequestId != "5" {
+		t.Fatalf("Expected the new member to be caught up in reply. Actual: %+v.", reconnected.last(t))
+	}
+	if err := room.Ready(Request{Member: reconnected}); err != nil {
+		t.Fatalf("Expected the new member to play the seat. Error: %s.", err)
+	}
+
+	if lobby.Replace(&testMember{}, Request{Member: &testMember{}}) {
+		t.Fatal("A member who isn't in a room has no seat to replace.")
+	}
+}
+
+func TestResumeAbandonedGame(t *testing.T) {
+	lobby := New()
+	host, guest, third := &testMember{}, &testMember{}, &testMember{}
+
+	room, _ := lobby.Create(Request{Member: host}, "host", "", 3)
+	lobby.Join(Request{Member: guest}, room.Id, "guest")
+	lobby.Join(Request{Member: third}, room.Id, "third")
+	room.Start(Request{Member: host})
+
+	lobby.Hold("guest", guest, time.Hour)
+	received := len(guest.received)
+	lobby.Leave(third)
+	if len(guest.received) != received {
+		t.Fatalf("Nothing should be sent to an away member. Actual: %+v.", guest.received[received:])
+	}
+
+	reconnected := &testMember{}
+	if lobby.Resume("guest", Request{Member: reconnected, Id: "6"}) {
+		t.Fatal("There should be no seat to resume once the game has been abandoned.")
+	}
+	var payload protocol.GameOverPayload
+	if reply := reconnected.last(t); reply.Type != protocol.Type_GameOver || reply.RequestId != "6" || reply.DecodePayload(&payload) != nil || !payload.Abandoned {
+		t.Fatalf("Expected the member to be told the game was abandoned. Actual: %+v.", reply)
+	}
+	if lobby.RoomOf(reconnected) != nil || len(lobby.held) != 0 {
+		t.Fatal("Expected the member to be back in the lobby.")
+	}
+}
+
+func TestHoldRunsOut(t *testing.T) {
+	lobby := New()
+	host, guest := &testMember{}, &testMember{}
+
+	room, _ := lobby.Create(Request{Member: host}, "host", "", 3)
+	lobby.Join(Request{Member: guest}, room.Id, "guest")
+
+	lobby.Hold("guest", guest, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	if lobby.RoomOf(guest) != nil || lobby.Resume("guest", Request{Member: &testMember{}}) {
+		t.Fatal("Expected the member to leave once the grace period ran out.")
+	}
+	if info := lobby.Open(); len(info) != 1 || len(info[0].Players) != 1 {
+		t.Fatalf("Expected only the host to be left. Actual: %+v.", info)
+	}
+
+	// Released seats go straight away
+	lobby.Hold("host", host, time.Hour)
+	lobby.Release("host")
+	if len(lobby.Open()) != 0 {
+		t.Fatalf("Expected the room to close. Actual: %+v.", lobby.Open())
+	}
+}
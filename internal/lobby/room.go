@@ -0,0 +1,253 @@
+package lobby
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/ishunyu/shithead/internal/engine"
+	"github.com/ishunyu/shithead/internal/protocol"
+)
+
+// Request is a member asking for something. The reply to them carries the Id of their request.
+type Request struct {
+	Member Member
+	Id     string
+}
+
+// RejectedError is returned when the engine turns down a game action.
+type RejectedError struct {
+	Status engine.Status
+}
+
+func (err *RejectedError) Error() string {
+	return fmt.Sprintf("Rejected with status %d", err.Status)
+}
+
+type seat struct {
+	// Nil once the member has left a game that has started, so the other seats keep their player ids.
+	member Member
+	name   string
+	// Set while the member's seat is held for them. Nothing is sent to them while they are away.
+	away bool
+	// How many of the game's events the member has been sent the state after.
+	seen int
+}
+
+// Room is a group of members sitting down to one game. Before the game starts members can come and go,
+// and once it starts each member plays the hand with the same index as their seat.
+type Room struct {
+	Id string
+
+	mu      sync.Mutex
+	ruleSet engine.RuleSet
+	seats   int
+	members []seat
+	game    *engine.Game
+}
+
+func (room *Room) Start(request Request) error {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	switch room.seatOf(request.Member) {
+	case -1:
+		return ErrNotInRoom
+	case 0:
+	default:
+		return ErrNotHost
+	}
+	if room.game != nil {
+		return ErrGameStarted
+	}
+
+	game, err := engine.NewGame(len(room.members), room.ruleSet)
+	if err != nil {
+		return err
+	}
+	room.game = game
+	room.broadcastRoom(request)
+	room.broadcastState(request)
+	return nil
+}
+
+func (room *Room) Swap(request Request, payload protocol.SwapPayload) error {
+	return room.act(request, func(playerId int) engine.Status {
+		return room.game.SwapCards(playerId, payload.InHand, payload.FaceUp)
+	})
+}
+
+func (room *Room) Ready(request Request) error {
+	return room.act(request, func(playerId int) engine.Status {
+		return room.game.MarkReady(playerId)
+	})
+}
+
+func (room *Room) Play(request Request, payload protocol.PlayPayload) error {
+	return room.act(request, func(playerId int) engine.Status {
+		play := engine.Play{Kind: engine.PlayCards, PlayerId: playerId, Cards: payload.Cards}
+		if payload.FaceDown {
+			play = engine.Play{Kind: engine.PlayFaceDown, PlayerId: playerId, Slot: payload.Slot}
+		}
+		return room.game.PlayHand(play).Status
+	})
+}
+
+func (room *Room) PickUp(request Request) error {
+	return room.act(request, func(playerId int) engine.Status {
+		return room.game.PlayHand(engine.Play{Kind: engine.PickUpPile, PlayerId: playerId}).Status
+	})
+}
+
+// Makes the game action for the requesting member and tells everyone how the game looks afterwards.
+func (room *Room) act(request Request, action func(playerId int) engine.Status) error {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	playerId := room.seatOf(request.Member)
+	if playerId < 0 {
+		return ErrNotInRoom
+	}
+	if room.game == nil {
+		return ErrNotStarted
+	}
+
+	if status := action(playerId); status != engine.Success {
+		return &RejectedError{Status: status}
+	}
+	room.broadcastState(request)
+	if room.game.IsOver() {
+		room.broadcast(request, protocol.Type_GameOver, func(int) any {
+			return protocol.GameOverPayload{Standings: room.game.Standings(), Shithead: room.game.Shithead()}
+		})
+	}
+	return nil
+}
+
+func (room *Room) join(request Request, name string) {
+	room.members = append(room.members, seat{member: request.Member, name: name})
+	room.broadcastRoom(request)
+}
+
+func (room *Room) leave(member Member) {
+	if room.game != nil {
+		if playerId := room.seatOf(member); playerId >= 0 {
+			room.members[playerId].member = nil
+		}
+	} else {
+		room.members = slices.DeleteFunc(room.members, func(seat seat) bool {
+			return seat.member == member
+		})
+	}
+	room.broadcastRoom(Request{Member: member})
+}
+
+// Returns how many seats still have a member in them.
+func (room *Room) occupied() int {
+	count := 0
+	for _, seat := range room.members {
+		if seat.member != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Ends a game that is still going because the member left it. Returns the game over everyone else was sent.
+func (room *Room) abandon(member Member) protocol.GameOverPayload {
+	room.leave(member)
+	gameOver := protocol.GameOverPayload{Standings: room.game.Standings(), Shithead: engine.ErrorPlayerId, Abandoned: true}
+	room.broadcast(Request{Member: member}, protocol.Type_GameOver, func(int) any {
+		return gameOver
+	})
+	room.members = nil
+	return gameOver
+}
+
+func (room *Room) setAway(member Member) {
+	if playerId := room.seatOf(member); playerId >= 0 {
+		room.members[playerId].away = true
+	}
+}
+
+// Seats the requesting member where the away member was. They are sent the room, and if the game has started,
+// the events they missed followed by the state.
+func (room *Room) resume(away Member, request Request) {
+	playerId := room.seatOf(away)
+	if playerId < 0 {
+		return
+	}
+	seat := &room.members[playerId]
+	seat.member = request.Member
+	seat.away = false
+
+	room.sendTo(playerId, request.Id, protocol.Type_Room, protocol.RoomPayload{Room: room.info(), Seat: playerId})
+	if room.game == nil {
+		return
+	}
+	room.sendTo(playerId, request.Id, protocol.Type_Events, protocol.EventsPayload{Events: room.game.EventsFor(playerId, seat.seen)})
+	view := room.game.ViewFor(playerId)
+	seat.seen = view.Seq
+	room.sendTo(playerId, request.Id, protocol.Type_State, protocol.StatePayload{Game: view})
+}
+
+// Returns the member's seat, or -1 if they are not in the room.
+func (room *Room) seatOf(member Member) int {
+	return slices.IndexFunc(room.members, func(seat seat) bool {
+		return seat.member == member
+	})
+}
+
+func (room *Room) info() protocol.RoomInfo {
+	players := make([]string, 0, len(room.members))
+	for _, seat := range room.members {
+		players = append(players, seat.name)
+	}
+	return protocol.RoomInfo{
+		Id:      room.Id,
+		Rules:   room.ruleSet.Name,
+		Seats:   room.seats,
+		Players: players,
+		Started: room.game != nil,
+	}
+}
+
+func (room *Room) broadcastRoom(request Request) {
+	info := room.info()
+	room.broadcast(request, protocol.Type_Room, func(playerId int) any {
+		return protocol.RoomPayload{Room: info, Seat: playerId}
+	})
+}
+
+// Sends every member the game as they are allowed to see it.
+func (room *Room) broadcastState(request Request) {
+	room.broadcast(request, protocol.Type_State, func(playerId int) any {
+		view := room.game.ViewFor(playerId)
+		room.members[playerId].seen = view.Seq
+		return protocol.StatePayload{Game: view}
+	})
+}
+
+// Sends a message to every member, built for their seat. The requesting member's copy carries the request id.
+func (room *Room) broadcast(request Request, messageType protocol.MessageType, payloadFor func(playerId int) any) {
+	for playerId, seat := range room.members {
+		if seat.member == nil || seat.away {
+			continue
+		}
+		requestId := ""
+		if seat.member == request.Member {
+			requestId = request.Id
+		}
+		room.sendTo(playerId, requestId, messageType, payloadFor(playerId))
+	}
+}
+
+func (room *Room) sendTo(playerId int, requestId string, messageType protocol.MessageType, payload any) {
+	envelope, err := protocol.NewEnvelope(messageType, requestId, payload)
+	if err != nil {
+		log.Printf("Error %s when building %s message for room %s", err, messageType, room.Id)
+		return
+	}
+	room.members[playerId].member.Send(envelope)
+}
@@ -0,0 +1,210 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ishunyu/shithead/internal/engine"
+)
+
+// Bumped whenever a message changes in a way older clients or servers can't read.
+const Version int = 1
+
+type MessageType string
+
+const (
+	// Client: open a room and take its first seat as the host.
+	Type_Create MessageType = "create"
+	// Client: ask for the rooms that can still be joined.
+	Type_List MessageType = "list"
+	// Client: take a seat in a room.
+	Type_Join MessageType = "join"
+	// Client: start the game in the room. Only the host can.
+	Type_Start MessageType = "start"
+	// Client: play cards, or a face down card by its slot.
+	Type_Play MessageType = "play"
+	// Client: pick up the in play pile.
+	Type_PickUp MessageType = "pickUp"
+	// Client: swap a card in hand with a face up card during the setup phase.
+	Type_Swap MessageType = "swap"
+	// Client: finish the setup phase.
+	Type_Ready MessageType = "ready"
+	// Client: give up the seat and leave the room.
+	Type_Leave MessageType = "leave"
+
+	// Server: the rooms that can still be joined.
+	Type_Rooms MessageType = "rooms"
+	// Server: who is in the client's room. Sent whenever someone joins or leaves.
+	Type_Room MessageType = "room"
+	// Server: the game as the client is allowed to see it.
+	Type_State MessageType = "state"
+	// Server: what happened in the game while the client was disconnected. Sent when it reconnects, just before the state.
+	Type_Events MessageType = "events"
+	// Server: the request could not be carried out.
+	Type_Error MessageType = "error"
+	// Server: the game is over.
+	Type_GameOver MessageType = "gameOver"
+)
+
+// Envelope wraps every message sent in either direction.
+// RequestId is chosen by the client and copied into the server's reply, so the client can match them up.
+type Envelope struct {
+	Version   int             `json:"version"`
+	Type      MessageType     `json:"type"`
+	RequestId string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// Rules names one of the engine's preset rule sets. It defaults to the standard rules.
+type CreatePayload struct {
+	Name  string `json:"name"`
+	Rules string `json:"rules,omitempty"`
+	Seats int    `json:"seats"`
+}
+
+type JoinPayload struct {
+	RoomId string `json:"roomId"`
+	Name   string `json:"name"`
+}
+
+// Cards are played from the hand or face up cards. If FaceDown is set, the face down card in Slot is played instead.
+type PlayPayload struct {
+	Cards    []engine.Card `json:"cards,omitempty"`
+	FaceDown bool          `json:"faceDown,omitempty"`
+	Slot     int           `json:"slot,omitempty"`
+}
+
+type SwapPayload struct {
+	InHand engine.Card `json:"inHand"`
+	FaceUp engine.Card `json:"faceUp"`
+}
+
+// RoomInfo describes a room. Players are listed by name in seat order, so the host is first.
+type RoomInfo struct {
+	Id      string   `json:"id"`
+	Rules   string   `json:"rules"`
+	Seats   int      `json:"seats"`
+	Players []string `json:"players"`
+	Started bool     `json:"started"`
+}
+
+type RoomsPayload struct {
+	Rooms []RoomInfo `json:"rooms"`
+}
+
+// Seat is the client's place in Players, which becomes its player id once the game starts.
+type RoomPayload struct {
+	Room RoomInfo `json:"room"`
+	Seat int      `json:"seat"`
+}
+
+type StatePayload struct {
+	Game engine.GameView `json:"game"`
+}
+
+type EventsPayload struct {
+	Events []engine.Event `json:"events"`
+}
+
+type ErrorCode string
+
+const (
+	// The message could not be decoded.
+	Error_BadMessage ErrorCode = "badMessage"
+	// The message was sent with a different protocol version.
+	Error_UnsupportedVersion ErrorCode = "unsupportedVersion"
+	// The message type is not one the server accepts.
+	Error_UnknownType ErrorCode = "unknownType"
+	// The client's session has ended or expired. The server closes the connection after sending it.
+	Error_Unauthorized ErrorCode = "unauthorized"
+	// The request needs a seat in a game, but the client doesn't have one.
+	Error_NotInGame ErrorCode = "notInGame"
+	// The room doesn't exist or can no longer be joined.
+	Error_NoSuchRoom ErrorCode = "noSuchRoom"
+	// Every seat in the room is taken.
+	Error_RoomFull ErrorCode = "roomFull"
+	// The client already has a seat in a room.
+	Error_InRoom ErrorCode = "inRoom"
+	// Only the host can do that.
+	Error_NotHost ErrorCode = "notHost"
+	// The game in the room has already started.
+	Error_GameStarted ErrorCode = "gameStarted"
+	// The game in the room hasn't started yet.
+	Error_NotStarted ErrorCode = "notStarted"
+	// The room could not be set up or started as asked, e.g. an unknown rule set or too few players.
+	Error_InvalidRoom ErrorCode = "invalidRoom"
+	// The engine turned the request down. Status says why.
+	Error_Rejected ErrorCode = "rejected"
+)
+
+type ErrorPayload struct {
+	Code    ErrorCode     `json:"code"`
+	Status  engine.Status `json:"status,omitempty"`
+	Message string        `json:"message"`
+}
+
+// Abandoned is set when the game ended because a player left, in which case Shithead is not set.
+type GameOverPayload struct {
+	Standings []int `json:"standings"`
+	Shithead  int   `json:"shithead"`
+	Abandoned bool  `json:"abandoned,omitempty"`
+}
+
+// Wraps the payload in an envelope of the current version. A nil payload is left out.
+func NewEnvelope(messageType MessageType, requestId string, payload any) (Envelope, error) {
+	envelope := Envelope{Version: Version, Type: messageType, RequestId: requestId}
+	if payload == nil {
+		return envelope, nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("Invalid %s payload: %w", messageType, err)
+	}
+	envelope.Payload = data
+	return envelope, nil
+}
+
+// Builds an error reply to the request.
+func NewError(requestId string, code ErrorCode, status engine.Status, message string) Envelope {
+	// An ErrorPayload always encodes
+	envelope, _ := NewEnvelope(Type_Error, requestId, ErrorPayload{Code: code, Status: status, Message: message})
+	return envelope
+}
+
+// Decodes an envelope, leaving its payload for DecodePayload.
+func Decode(data []byte) (Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return Envelope{}, fmt.Errorf("Invalid message: %w", err)
+	}
+	if envelope.Type == "" {
+		return envelope, fmt.Errorf("Message has no type")
+	}
+	return envelope, nil
+}
+
+// Decodes the envelope's payload into v. Fields v doesn't have are an error.
+func (envelope Envelope) DecodePayload(v any) error {
+	if len(envelope.Payload) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(envelope.Payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("Invalid %s payload: %w", envelope.Type, err)
+	}
+	return nil
+}
+
+// Whether the message type is one a client may send.
+func (messageType MessageType) IsClient() bool {
+	switch messageType {
+	case Type_Create, Type_List, Type_Join, Type_Start, Type_Play, Type_PickUp, Type_Swap, Type_Ready, Type_Leave:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/ishunyu/shithead/internal/engine"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	cards := []engine.Card{{Suit: engine.Club, Rank: engine.Five}, {Suit: engine.Heart, Rank: engine.Five, Deck: 1}}
+	envelope, err := NewEnvelope(Type_Play, "7", PlayPayload{Cards: cards})
+	if err != nil {
+		t.Fatalf("Expected the envelope to be built. Error: %s.", err)
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Expected the envelope to encode. Error: %s.", err)
+	}
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Expected the envelope to decode. Error: %s.", err)
+	}
+	if decoded.Version != Version || decoded.Type != Type_Play || decoded.RequestId != "7" {
+		t.Fatalf("Envelope mismatch. Expected: %+v, actual: %+v.", envelope, decoded)
+	}
+
+	var payload PlayPayload
+	if err := decoded.DecodePayload(&payload); err != nil {
+		t.Fatalf("Expected the payload to decode. Error: %s.", err)
+	}
+	if !slices.Equal(payload.Cards, cards) || payload.FaceDown {
+		t.Fatalf("Payload mismatch. Expected: %v, actual: %+v.", cards, payload)
+	}
+}
+
+func TestCardFields(t *testing.T) {
+	envelope, err := NewEnvelope(Type_Swap, "", SwapPayload{InHand: engine.Card{Suit: engine.Club, Rank: engine.Five}})
+	if err != nil {
+		t.Fatalf("Expected the envelope to be built. Error: %s.", err)
+	}
+
+	expected := `{"inHand":{"suit":1,"rank":5,"deck":0},"faceUp":{"suit":0,"rank":0,"deck":0}}`
+	if string(envelope.Payload) != expected {
+		t.Fatalf("Expected camelCase card fields. Expected: %s, actual: %s.", expected, envelope.Payload)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	testCases := []string{
+		`start`,
+		`{"version": 1}`,
+		`[1, 2]`,
+	}
+
+	for _, testCase := range testCases {
+		if _, err := Decode([]byte(testCase)); err == nil {
+			t.Fatalf("Expected %s not to decode.", testCase)
+		}
+	}
+}
+
+func TestDecodePayloadUnknownField(t *testing.T) {
+	envelope, err := Decode([]byte(`{"version": 1, "type": "swap", "payload": {"inHand": {}, "faceDown": {}}}`))
+	if err != nil {
+		t.Fatalf("Expected the envelope to decode. Error: %s.", err)
+	}
+
+	var payload SwapPayload
+	if err := envelope.DecodePayload(&payload); err == nil {
+		t.Fatal("Expected a payload with an unknown field to be rejected.")
+	}
+}
+
+func TestNewError(t *testing.T) {
+	envelope := NewError("3", Error_Rejected, engine.Play_CardTooLow, "Card is too low")
+	if envelope.Type != Type_Error || envelope.RequestId != "3" {
+		t.Fatalf("Expected an error reply to request 3. Actual: %+v.", envelope)
+	}
+
+	var payload ErrorPayload
+	if err := envelope.DecodePayload(&payload); err != nil {
+		t.Fatalf("Expected the payload to decode. Error: %s.", err)
+	}
+	if payload.Code != Error_Rejected || payload.Status != engine.Play_CardTooLow {
+		t.Fatalf("Error payload mismatch. Actual: %+v.", payload)
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	for _, messageType := range []MessageType{Type_Create, Type_List, Type_Join, Type_Start, Type_Play, Type_PickUp, Type_Swap, Type_Ready, Type_Leave} {
+		if !messageType.IsClient() {
+			t.Fatalf("Expected %s to be a client message.", messageType)
+		}
+	}
+	for _, messageType := range []MessageType{Type_Rooms, Type_Room, Type_State, Type_Events, Type_Error, Type_GameOver, "shuffle"} {
+		if messageType.IsClient() {
+			t.Fatalf("Expected %s not to be a client message.", messageType)
+		}
+	}
+}
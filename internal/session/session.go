@@ -0,0 +1,99 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// How many random bytes go into a token.
+const tokenBytes = 16
+
+type Token string
+
+type Session struct {
+	Token     Token     `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Store keeps sessions in memory. A session expires once it hasn't been used for the store's time to live.
+type Store struct {
+	mu       sync.Mutex
+	sessions map[Token]*Session
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		sessions: make(map[Token]*Session),
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// Starts a new session with a random token.
+func (store *Store) Create() (Session, error) {
+	data := make([]byte, tokenBytes)
+	if _, err := rand.Read(data); err != nil {
+		return Session{}, fmt.Errorf("Could not make a session token: %w", err)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	session := &Session{Token: Token(hex.EncodeToString(data)), ExpiresAt: store.now().Add(store.ttl)}
+	store.sessions[session.Token] = session
+	return *session, nil
+}
+
+// Whether the session exists and hasn't expired. Using a session pushes its expiry back.
+func (store *Store) Touch(token Token) bool {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	session, ok := store.sessions[token]
+	if !ok {
+		return false
+	}
+	now := store.now()
+	if !now.Before(session.ExpiresAt) {
+		delete(store.sessions, token)
+		return false
+	}
+	session.ExpiresAt = now.Add(store.ttl)
+	return true
+}
+
+// Ends the session. Returns false if there was no such session.
+func (store *Store) End(token Token) bool {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	_, ok := store.sessions[token]
+	delete(store.sessions, token)
+	return ok
+}
+
+// Ends every expired session and returns their tokens. Sessions that keep returns true for are touched instead.
+func (store *Store) Sweep(keep func(token Token) bool) []Token {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	ended := make([]Token, 0)
+	for token, session := range store.sessions {
+		if now.Before(session.ExpiresAt) {
+			continue
+		}
+		if keep(token) {
+			session.ExpiresAt = now.Add(store.ttl)
+			continue
+		}
+		delete(store.sessions, token)
+		ended = append(ended, token)
+	}
+	return ended
+}
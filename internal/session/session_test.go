@@ -0,0 +1,78 @@
+package session
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// Returns a store whose clock only moves when the returned function is called.
+func newTestStore(ttl time.Duration) (*Store, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewStore(ttl)
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCreateAndEnd(t *testing.T) {
+	store, _ := newTestStore(time.Minute)
+
+	session, err := store.Create()
+	if err != nil {
+		t.Fatalf("Expected the session to be created. Error: %s.", err)
+	}
+	other, _ := store.Create()
+	if session.Token == other.Token || len(session.Token) != 2*tokenBytes {
+		t.Fatalf("Expected distinct random tokens. Actual: %s, %s.", session.Token, other.Token)
+	}
+
+	if !store.Touch(session.Token) {
+		t.Fatal("Expected a new session to be valid.")
+	}
+	if !store.End(session.Token) {
+		t.Fatal("Expected the session to end.")
+	}
+	if store.Touch(session.Token) || store.End(session.Token) {
+		t.Fatal("Expected an ended session to be gone.")
+	}
+	if store.Touch("made up") {
+		t.Fatal("Expected an unknown token to be rejected.")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	store, advance := newTestStore(time.Minute)
+	session, _ := store.Create()
+
+	// Using the session keeps it alive
+	advance(50 * time.Second)
+	if !store.Touch(session.Token) {
+		t.Fatal("Expected the session to be valid before it expires.")
+	}
+	advance(50 * time.Second)
+	if !store.Touch(session.Token) {
+		t.Fatal("Expected touching the session to push its expiry back.")
+	}
+
+	advance(time.Minute)
+	if store.Touch(session.Token) {
+		t.Fatal("Expected the session to have expired.")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	store, advance := newTestStore(time.Minute)
+	idle, _ := store.Create()
+	connected, _ := store.Create()
+	advance(30 * time.Second)
+	fresh, _ := store.Create()
+
+	advance(45 * time.Second)
+	ended := store.Sweep(func(token Token) bool { return token == connected.Token })
+	if !slices.Equal(ended, []Token{idle.Token}) {
+		t.Fatalf("Expected only the idle session to end. Actual: %v.", ended)
+	}
+	if !store.Touch(connected.Token) || !store.Touch(fresh.Token) {
+		t.Fatal("Expected the kept and unexpired sessions to be valid.")
+	}
+}
@@ -1,66 +1,111 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/ishunyu/shithead/internal/lobby"
+	"github.com/ishunyu/shithead/internal/protocol"
+	"github.com/ishunyu/shithead/internal/session"
 )
 
+const (
+	// How long a session lasts without being used or connected
+	sessionTTL = 30 * time.Minute
+	// How often expired sessions are cleared out
+	sweepInterval = time.Minute
+)
+
+var origins = []string{"null", "http://localhost:8080"}
+
+func isAllowedOrigin(r *http.Request) bool {
+	var origin = r.Header.Get("origin")
+	for _, allowOrigin := range origins {
+		if origin == allowOrigin {
+			return true
+		}
+	}
+	return false
+}
+
 type webSocketHandler struct {
 	upgrader websocket.Upgrader
+	server   *server
 }
 
 func (wsh webSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Browsers can't set headers on a WebSocket, so the token can also come in the query string
+	token := session.Token(r.URL.Query().Get("token"))
+	if token == "" {
+		token = bearerToken(r)
+	}
+	if !wsh.server.sessions.Touch(token) {
+		http.Error(w, "Unknown or expired session", http.StatusUnauthorized)
+		return
+	}
+
 	c, err := wsh.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("error %s when upgrading connection to websocket", err)
 		return
 	}
-	defer c.Close()
+	client := newClient(c, token, wsh.server)
+	go client.write()
+	defer client.close()
+
+	wsh.server.connect(client)
+	defer wsh.server.disconnect(client)
 
+	// A connection that stops answering pings is closed, so its seat can be held.
+	// Each answer also keeps the session alive, however long the player sits idle.
+	c.SetReadDeadline(time.Now().Add(pongWait))
+	c.SetPongHandler(func(string) error {
+		wsh.server.sessions.Touch(token)
+		return c.SetReadDeadline(time.Now().Add(pongWait))
+	})
 	for {
 		mt, message, err := c.ReadMessage()
 		if err != nil {
 			log.Printf("Error %s when reading message from client", err)
 			return
 		}
+		c.SetReadDeadline(time.Now().Add(pongWait))
 		if mt == websocket.BinaryMessage {
-			err = c.WriteMessage(websocket.TextMessage, []byte("server doesn't support binary messages"))
-			if err != nil {
-				log.Printf("Error %s when sending message to client", err)
-			}
-			return
+			client.sendError("", protocol.Error_BadMessage, "server doesn't support binary messages")
+			continue
 		}
 		log.Printf("Receive message %s", string(message))
-		command := strings.Trim(string(message), "\n")
-		if command == "start" {
-			err = c.WriteMessage(websocket.TextMessage, []byte("Game starting..."))
-			if err != nil {
-				log.Printf("Error %s when sending message to client", err)
-			}
+		if !client.handle(message) {
 			return
 		}
 	}
-
 }
 
 func main() {
-	var origins = []string{"null", "http://localhost:8080"}
+	grace := flag.Duration("grace", 2*time.Minute, "how long a dropped player's seat is held for them to reconnect")
+	flag.Parse()
+
+	server := newServer(lobby.New(), session.NewStore(sessionTTL), *grace)
+	go server.sweep(sweepInterval)
+
+	log.Print("Starting shithead server...\n")
+	log.Fatal(http.ListenAndServe("localhost:8080", newMux(server)))
+}
+
+// Routes the session endpoints and the WebSocket to the server.
+func newMux(server *server) *http.ServeMux {
 	webSocketHandler := webSocketHandler{
+		server: server,
 		upgrader: websocket.Upgrader{ // Resolve cross-domain problems
-			CheckOrigin: func(r *http.Request) bool {
-				var origin = r.Header.Get("origin")
-				for _, allowOrigin := range origins {
-					if origin == allowOrigin {
-						return true
-					}
-				}
-				return false
-			}},
+			CheckOrigin: isAllowedOrigin,
+		},
 	}
-	http.Handle("/", webSocketHandler)
-	log.Print("Starting shithead server...\n")
-	log.Fatal(http.ListenAndServe("localhost:8080", nil))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/session/init", server.sessionInit)
+	mux.HandleFunc("/session/end", server.sessionEnd)
+	mux.Handle("/", webSocketHandler)
+	return mux
 }
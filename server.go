@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ishunyu/shithead/internal/lobby"
+	"github.com/ishunyu/shithead/internal/session"
+)
+
+// The server ties sessions to the connections currently using them. A session has at most one connection.
+// When a connection drops, the session's seat is held for the grace period so it can reconnect and carry on.
+type server struct {
+	lobby    *lobby.Lobby
+	sessions *session.Store
+	grace    time.Duration
+
+	// Locked before the lobby, so connecting and disconnecting change the lobby one at a time
+	mu      sync.Mutex
+	clients map[session.Token]*client
+}
+
+func newServer(lobby *lobby.Lobby, sessions *session.Store, grace time.Duration) *server {
+	return &server{
+		lobby:    lobby,
+		sessions: sessions,
+		grace:    grace,
+		clients:  make(map[session.Token]*client),
+	}
+}
+
+// POST /session/init starts a session. The token in the reply is needed to connect the WebSocket.
+func (s *server) sessionInit(w http.ResponseWriter, r *http.Request) {
+	if !allowCrossOrigin(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	created, err := s.sessions.Create()
+	if err != nil {
+		log.Printf("Error %s when creating session", err)
+		http.Error(w, "Could not create session", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(created); err != nil {
+		log.Printf("Error %s when sending session", err)
+	}
+}
+
+// POST /session/end ends the session in the Authorization header and closes its connection.
+func (s *server) sessionEnd(w http.ResponseWriter, r *http.Request) {
+	if !allowCrossOrigin(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := bearerToken(r)
+	if !s.sessions.End(token) {
+		http.Error(w, "Unknown or expired session", http.StatusUnauthorized)
+		return
+	}
+	s.kick(token)
+	s.lobby.Release(string(token))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Ends expired sessions every interval. Sessions with a connection don't expire.
+func (s *server) sweep(interval time.Duration) {
+	for range time.Tick(interval) {
+		for _, token := range s.sessions.Sweep(s.isConnected) {
+			s.lobby.Release(string(token))
+		}
+	}
+}
+
+func (s *server) isConnected(token session.Token) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.clients[token]
+	return ok
+}
+
+// Registers the client as its session's connection, and gives it the session's seat if it has one.
+// A connection the session already has is closed and replaced, since it is most likely dead without knowing it.
+func (s *server) connect(c *client) {
+	// The lobby is changed under the lock so it can't run in between a disconnect and its hold
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request := lobby.Request{Member: c}
+	old, ok := s.clients[c.token]
+	s.clients[c.token] = c
+	if ok {
+		old.conn.Close()
+		s.lobby.Replace(old, request)
+		return
+	}
+	s.lobby.Resume(string(c.token), request)
+}
+
+// Holds the client's seat for the grace period and starts its session's expiry from now.
+// If the session is over, the seat is given up straight away. A client that has been replaced has no seat left.
+func (s *server) disconnect(c *client) {
+	// Touched before locking, as sweeping checks connections while the session store is locked
+	active := s.sessions.Touch(c.token)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clients[c.token] != c {
+		return
+	}
+	delete(s.clients, c.token)
+	if active {
+		s.lobby.Hold(string(c.token), c, s.grace)
+	} else {
+		s.lobby.Leave(c)
+	}
+}
+
+// Closes the session's connection, if it has one.
+func (s *server) kick(token session.Token) {
+	s.mu.Lock()
+	c, ok := s.clients[token]
+	s.mu.Unlock()
+
+	if ok {
+		c.conn.Close()
+	}
+}
+
+func bearerToken(r *http.Request) session.Token {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return session.Token(token)
+}
+
+// Sets the CORS headers for pages served from the allowed origins. Returns false if the request was a preflight
+// that has been answered, or came from an origin that isn't allowed.
+func allowCrossOrigin(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("origin") == "" {
+		return true
+	}
+	if !isAllowedOrigin(r) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("origin"))
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ishunyu/shithead/internal/lobby"
+	"github.com/ishunyu/shithead/internal/protocol"
+	"github.com/ishunyu/shithead/internal/session"
+)
+
+// Starts a server on a random port whose sessions last an hour. It is closed when the test ends.
+func newTestServer(t *testing.T) (*server, *httptest.Server) {
+	t.Helper()
+	return newTestServerTTL(t, time.Hour)
+}
+
+func newTestServerTTL(t *testing.T, ttl time.Duration) (*server, *httptest.Server) {
+	t.Helper()
+	s := newServer(lobby.New(), session.NewStore(ttl), time.Hour)
+	httpServer := httptest.NewServer(newMux(s))
+	t.Cleanup(httpServer.Close)
+	return s, httpServer
+}
+
+func post(t *testing.T, address string, token session.Token) *http.Response {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodPost, address, nil)
+	if err != nil {
+		t.Fatalf("Expected the request to be built. Error: %s.", err)
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+string(token))
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Expected the request to be sent. Error: %s.", err)
+	}
+	response.Body.Close()
+	return response
+}
+
+// Connects the WebSocket with the token. The connection is closed when the test ends.
+func dial(t *testing.T, httpServer *httptest.Server, token session.Token) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	address := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/?token=" + url.QueryEscape(string(token))
+	conn, response, err := websocket.DefaultDialer.Dial(address, http.Header{"Origin": {"http://localhost:8080"}})
+	if conn != nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, response, err
+}
+
+func readEnvelope(t *testing.T, conn *websocket.Conn) protocol.Envelope {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Expected a message. Error: %s.", err)
+	}
+	envelope, err := protocol.Decode(data)
+	if err != nil {
+		t.Fatalf("Expected the message to decode. Error: %s.", err)
+	}
+	return envelope
+}
+
+func send(t *testing.T, conn *websocket.Conn, messageType protocol.MessageType, requestId string, payload any) {
+	t.Helper()
+	envelope, err := protocol.NewEnvelope(messageType, requestId, payload)
+	if err != nil {
+		t.Fatalf("Expected the envelope to be built. Error: %s.", err)
+	}
+	if err := conn.WriteJSON(envelope); err != nil {
+		t.Fatalf("Expected the message to be sent. Error: %s.", err)
+	}
+}
+
+// Waits for the server to close the connection.
+func expectClosed(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
+			t.Fatal("Expected the server to close the connection.")
+		}
+		return
+	}
+}
+
+func TestSessionInit(t *testing.T) {
+	s, httpServer := newTestServer(t)
+
+	response, err := http.Get(httpServer.URL + "/session/init")
+	if err != nil {
+		t.Fatalf("Expected the request to be sent. Error: %s.", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected GET to be refused. Actual: %d.", response.StatusCode)
+	}
+
+	response, err = http.Post(httpServer.URL+"/session/init", "", nil)
+	if err != nil {
+		t.Fatalf("Expected the request to be sent. Error: %s.", err)
+	}
+	defer response.Body.Close()
+	var created session.Session
+	if err := json.NewDecoder(response.Body).Decode(&created); err != nil {
+		t.Fatalf("Expected the session to decode. Error: %s.", err)
+	}
+	if response.StatusCode != http.StatusOK || !s.sessions.Touch(created.Token) {
+		t.Fatalf("Expected a valid session. Status: %d, session: %+v.", response.StatusCode, created)
+	}
+}
+
+func TestSessionEnd(t *testing.T) {
+	s, httpServer := newTestServer(t)
+	created, _ := s.sessions.Create()
+
+	response, err := http.Get(httpServer.URL + "/session/end")
+	if err != nil {
+		t.Fatalf("Expected the request to be sent. Error: %s.", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected GET to be refused. Actual: %d.", response.StatusCode)
+	}
+	if status := post(t, httpServer.URL+"/session/end", "").StatusCode; status != http.StatusUnauthorized {
+		t.Fatalf("Expected a missing token to be unauthorized. Actual: %d.", status)
+	}
+	if status := post(t, httpServer.URL+"/session/end", "made up").StatusCode; status != http.StatusUnauthorized {
+		t.Fatalf("Expected an unknown token to be unauthorized. Actual: %d.", status)
+	}
+
+	conn, _, err := dial(t, httpServer, created.Token)
+	if err != nil {
+		t.Fatalf("Expected the WebSocket to connect. Error: %s.", err)
+	}
+	send(t, conn, protocol.Type_List, "1", nil)
+	readEnvelope(t, conn)
+
+	if status := post(t, httpServer.URL+"/session/end", created.Token).StatusCode; status != http.StatusNoContent {
+		t.Fatalf("Expected the session to end. Actual: %d.", status)
+	}
+	expectClosed(t, conn)
+	if s.sessions.Touch(created.Token) {
+		t.Fatal("Expected the session to be gone.")
+	}
+	if status := post(t, httpServer.URL+"/session/end", created.Token).StatusCode; status != http.StatusUnauthorized {
+		t.Fatalf("Expected an ended session to be unauthorized. Actual: %d.", status)
+	}
+}
+
+func TestWebSocketUnauthorized(t *testing.T) {
+	_, httpServer := newTestServer(t)
+
+	for _, token := range []session.Token{"", "made up"} {
+		_, response, err := dial(t, httpServer, token)
+		if err == nil || response == nil || response.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Expected token %q to be unauthorized. Error: %v, response: %+v.", token, err, response)
+		}
+	}
+}
+
+func TestWebSocketReplaced(t *testing.T) {
+	s, httpServer := newTestServer(t)
+	created, _ := s.sessions.Create()
+
+	old, _, err := dial(t, httpServer, created.Token)
+	if err != nil {
+		t.Fatalf("Expected the WebSocket to connect. Error: %s.", err)
+	}
+	send(t, old, protocol.Type_Create, "1", protocol.CreatePayload{Name: "host", Seats: 2})
+	if reply := readEnvelope(t, old); reply.Type != protocol.Type_Room {
+		t.Fatalf("Expected the room to be created. Actual: %+v.", reply)
+	}
+
+	replacement, _, err := dial(t, httpServer, created.Token)
+	if err != nil {
+		t.Fatalf("Expected a second connection to replace the first. Error: %s.", err)
+	}
+	expectClosed(t, old)
+
+	var payload protocol.RoomPayload
+	if reply := readEnvelope(t, replacement); reply.Type != protocol.Type_Room || reply.DecodePayload(&payload) != nil || payload.Seat != 0 {
+		t.Fatalf("Expected the new connection to be given the seat. Actual: %+v.", reply)
+	}
+	send(t, replacement, protocol.Type_Leave, "2", nil)
+	if reply := readEnvelope(t, replacement); reply.Type != protocol.Type_Rooms || reply.RequestId != "2" {
+		t.Fatalf("Expected the new connection to be able to leave the room. Actual: %+v.", reply)
+	}
+}
+
+func TestWebSocketKeepsSessionAlive(t *testing.T) {
+	ttl := 200 * time.Millisecond
+	s, httpServer := newTestServerTTL(t, ttl)
+	created, _ := s.sessions.Create()
+
+	conn, _, err := dial(t, httpServer, created.Token)
+	if err != nil {
+		t.Fatalf("Expected the WebSocket to connect. Error: %s.", err)
+	}
+	// Answer pings for longer than the session lasts, without sending any messages
+	for range 6 {
+		time.Sleep(ttl / 2)
+		if err := conn.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("Expected the pong to be sent. Error: %s.", err)
+		}
+	}
+	time.Sleep(ttl / 4)
+
+	send(t, conn, protocol.Type_List, "1", nil)
+	if reply := readEnvelope(t, conn); reply.Type != protocol.Type_Rooms {
+		t.Fatalf("Expected an idle but connected session to stay alive. Actual: %+v.", reply)
+	}
+}
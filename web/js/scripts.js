@@ -1,20 +1,86 @@
-// Create WebSocket connection.
-const socket = new WebSocket("ws://localhost:8080");
+var socket;
 
-// Connection opened
-socket.addEventListener("open", (event) => {
-    console.log("Connection opened.")
-});
+// How many times in a row connecting has failed, for backing off between retries
+var failures = 0;
+// The browser hides why a handshake failed, so after this many in a row the session is assumed to have expired
+const maxHandshakeFailures = 3;
 
-// Listen for messages
-socket.addEventListener("message", (event) => {
-  document.getElementById('response').innerHTML = event.data;
-});
+// Create WebSocket connection with the session's token.
+function connect(token) {
+	socket = new WebSocket("ws://localhost:8080/?token=" + encodeURIComponent(token));
+	var opened = false;
+	var unauthorized = false;
 
+	// Connection opened
+	socket.addEventListener("open", (event) => {
+		console.log("Connection opened.")
+		opened = true;
+		failures = 0;
+	});
+
+	// Listen for messages. Server data is shown as text, since it includes names chosen by other players.
+	socket.addEventListener("message", (event) => {
+		document.getElementById('response').textContent = event.data;
+		var message = JSON.parse(event.data);
+		if (message.type === "error" && message.payload.code === "unauthorized") {
+			unauthorized = true;
+		}
+	});
+
+	// The server holds our seat for a while, so reconnect with the same token to pick up where we left off.
+	// Once the session is over, start a new one instead.
+	socket.addEventListener("close", (event) => {
+		if (!opened) {
+			failures++;
+		}
+		if (unauthorized || failures >= maxHandshakeFailures) {
+			console.log("Session is over, starting a new one.")
+			retry(startSession);
+			return;
+		}
+		console.log("Connection closed, reconnecting.")
+		retry(() => connect(token));
+	});
+}
+
+// Runs the attempt after a delay that doubles with every failure, up to half a minute.
+function retry(attempt) {
+	var delay = Math.min(1000 * 2 ** failures, 30000);
+	setTimeout(attempt, delay);
+}
+
+// Start a session, then connect with it.
+function startSession() {
+	fetch("http://localhost:8080/session/init", { method: "POST" })
+		.then((response) => response.json())
+		.then((session) => {
+			failures = 0;
+			connect(session.token);
+		})
+		.catch((error) => {
+			console.log("Could not start a session: " + error)
+			failures++;
+			retry(startSession);
+		});
+}
+
+startSession();
+
+var requestId = 0;
+
+// A command is a message type, optionally followed by its JSON payload, e.g. `swap {"inHand": ..., "faceUp": ...}`.
 function send() {
-	var command = document.getElementById('command').value;
-    // console.log(command)
-    socket.send(command)
+	var command = document.getElementById('command').value.trim();
+	var space = command.indexOf(' ');
+	var message = {
+		version: 1,
+		type: space < 0 ? command : command.substring(0, space),
+		requestId: String(++requestId)
+	};
+	if (space >= 0) {
+		message.payload = JSON.parse(command.substring(space + 1));
+	}
+	socket.send(JSON.stringify(message));
 }
 
 function commandKeyDown(ele) {